@@ -0,0 +1,18 @@
+package ast
+
+import "github.com/NicoNex/monkey/token"
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (f *FloatLiteral) ENode() {}
+
+func (f *FloatLiteral) Literal() string {
+	return f.Token.Lit
+}
+
+func (f *FloatLiteral) String() string {
+	return f.Token.Lit
+}
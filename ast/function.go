@@ -9,7 +9,10 @@ import (
 type FunctionLiteral struct {
 	Token  token.Token
 	Params []*Identifier
+	Ret    *Type
 	Body   *BlockStatement
+	// Number of local variables, parameters included, set by the resolver.
+	Slots int
 }
 
 func (f *FunctionLiteral) ENode() {}
@@ -25,6 +28,16 @@ func (f *FunctionLiteral) String() string {
 		params = append(params, p.String())
 	}
 
+	if f.Ret != nil {
+		return fmt.Sprintf(
+			"%s(%s) -> %s %s",
+			f.Literal(),
+			strings.Join(params, ", "),
+			f.Ret,
+			f.Body,
+		)
+	}
+
 	return fmt.Sprintf(
 		"%s(%s) %s",
 		f.Literal(),
@@ -0,0 +1,30 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/NicoNex/monkey/token"
+	"strings"
+)
+
+type HashLiteral struct {
+	Token token.Token
+	Pairs map[Expression]Expression
+	// Keys of Pairs in the order they appear in the source.
+	Keys []Expression
+}
+
+func (h *HashLiteral) ENode() {}
+
+func (h *HashLiteral) Literal() string {
+	return h.Token.Lit
+}
+
+func (h *HashLiteral) String() string {
+	var pairs []string
+
+	for _, k := range h.Keys {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", k.String(), h.Pairs[k].String()))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+}
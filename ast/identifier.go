@@ -5,6 +5,17 @@ import "github.com/NicoNex/monkey/token"
 type Identifier struct {
 	Token token.Token
 	Value string
+	Type  *Type
+	// Set by the resolver for local variables, which are stored at slot
+	// Index of the environment Depth functions above the current one.
+	// All the other identifiers are looked up by name among the globals.
+	Local bool
+	Depth int
+	Index int
+	// Outer is the binding of the same name in the enclosing functions,
+	// used while the local slot hasn't been set yet, or nil if that's a
+	// global.
+	Outer *Identifier
 }
 
 func (i *Identifier) ENode() {}
@@ -14,5 +25,8 @@ func (i *Identifier) Literal() string {
 }
 
 func (i *Identifier) String() string {
+	if i.Type != nil {
+		return i.Value + ": " + i.Type.String()
+	}
 	return i.Value
 }
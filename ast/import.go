@@ -0,0 +1,25 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/NicoNex/monkey/token"
+	"strconv"
+)
+
+// ImportStatement is import "path" as name, Name is derived from the path
+// when it's omitted.
+type ImportStatement struct {
+	Token token.Token
+	Path  string
+	Name  *Identifier
+}
+
+func (i *ImportStatement) SNode() {}
+
+func (i *ImportStatement) Literal() string {
+	return i.Token.Lit
+}
+
+func (i *ImportStatement) String() string {
+	return fmt.Sprintf("%s %s as %s;", i.Literal(), strconv.Quote(i.Path), i.Name)
+}
@@ -1,10 +1,15 @@
 package ast
 
-import "github.com/NicoNex/monkey/token"
+import (
+	"github.com/NicoNex/monkey/token"
+	"math/big"
+)
 
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	// Big holds the value of the literals that don't fit in an int64.
+	Big *big.Int
 }
 
 func (i *IntegerLiteral) ENode() {}
@@ -8,8 +8,11 @@ import (
 
 type LetStatement struct {
 	Token token.Token
+	// Name holds the type annotation of the binding, if any.
 	Name  *Identifier
 	Value Expression
+	// Export is true for the bindings a module exports.
+	Export bool
 }
 
 func (ls *LetStatement) SNode() {}
@@ -21,11 +24,10 @@ func (ls *LetStatement) Literal() string {
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(fmt.Sprintf(
-		"%s %s = ",
-		ls.Literal(),
-		ls.Name.String(),
-	))
+	if ls.Export {
+		out.WriteString("export ")
+	}
+	out.WriteString(fmt.Sprintf("%s %s = ", ls.Literal(), ls.Name.String()))
 
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
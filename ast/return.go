@@ -18,7 +18,7 @@ func (r *ReturnStatement) SNode() {}
 
 func (r *ReturnStatement) String() string {
 	if r.Value != nil {
-		fmt.Sprintf("%s %s;", r.Literal(), r.Value)
+		return fmt.Sprintf("%s %s;", r.Literal(), r.Value)
 	}
 	return fmt.Sprintf("%s;", r.Literal())
 }
@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/NicoNex/monkey/token"
+)
+
+// SliceExpression is left[start:end:step], any of the bounds can be nil when
+// omitted.
+type SliceExpression struct {
+	Token token.Token
+	Left  Expression
+	Start Expression
+	End   Expression
+	Step  Expression
+}
+
+func (s *SliceExpression) ENode() {}
+
+func (s *SliceExpression) Literal() string {
+	return s.Token.Lit
+}
+
+func (s *SliceExpression) String() string {
+	var ret = fmt.Sprintf("%s:%s", exprString(s.Start), exprString(s.End))
+
+	if s.Step != nil {
+		ret += ":" + s.Step.String()
+	}
+	return fmt.Sprintf("(%s[%s])", s.Left.String(), ret)
+}
+
+// Returns the string representation of e or an empty string if e is nil.
+func exprString(e Expression) string {
+	if e == nil {
+		return ""
+	}
+	return e.String()
+}
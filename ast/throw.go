@@ -0,0 +1,21 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/NicoNex/monkey/token"
+)
+
+type ThrowStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (t *ThrowStatement) Literal() string {
+	return t.Token.Lit
+}
+
+func (t *ThrowStatement) SNode() {}
+
+func (t *ThrowStatement) String() string {
+	return fmt.Sprintf("%s %s;", t.Literal(), t.Value)
+}
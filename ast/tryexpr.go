@@ -0,0 +1,24 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/NicoNex/monkey/token"
+)
+
+// TryExpression is try { Body } catch (Param) { Catch }.
+type TryExpression struct {
+	Token token.Token
+	Body  *BlockStatement
+	Param *Identifier
+	Catch *BlockStatement
+}
+
+func (t *TryExpression) ENode() {}
+
+func (t *TryExpression) Literal() string {
+	return t.Token.Lit
+}
+
+func (t *TryExpression) String() string {
+	return fmt.Sprintf("try %s catch (%s) %s", t.Body, t.Param, t.Catch)
+}
@@ -0,0 +1,42 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/NicoNex/monkey/token"
+	"strings"
+)
+
+// Type is an optional type annotation such as int, [string] or
+// fn(int, int) -> bool.
+type Type struct {
+	Token  token.Token
+	Name   string
+	Elem   *Type   // element type of array types
+	Params []*Type // parameter types of function types
+	Ret    *Type   // return type of function types
+}
+
+func (t *Type) Literal() string {
+	return t.Token.Lit
+}
+
+func (t *Type) String() string {
+	switch {
+	case t.Elem != nil:
+		return fmt.Sprintf("[%s]", t.Elem)
+
+	case t.Name == "fn":
+		var params []string
+
+		for _, p := range t.Params {
+			params = append(params, p.String())
+		}
+		if t.Ret != nil {
+			return fmt.Sprintf("fn(%s) -> %s", strings.Join(params, ", "), t.Ret)
+		}
+		return fmt.Sprintf("fn(%s)", strings.Join(params, ", "))
+
+	default:
+		return t.Name
+	}
+}
@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Returns the object representation of the big integer b, which is an
+// Integer whenever b fits in one.
+func bigtoo(b *big.Int) obj.Object {
+	if b.IsInt64() {
+		return itoo(b.Int64())
+	}
+	return &obj.BigInt{Value: b}
+}
+
+// Returns a new big.Int holding the value of the integer object o.
+func toBig(o obj.Object) *big.Int {
+	if b, ok := o.(*obj.BigInt); ok {
+		return new(big.Int).Set(b.Value)
+	}
+	return big.NewInt(o.(*obj.Integer).Value)
+}
+
+// Reports whether o is an integer, of any size.
+func isInteger(o obj.Object) bool {
+	return o.Type() == obj.INT || o.Type() == obj.BIGINT
+}
+
+// Reports whether the result of l op r overflows an int64.
+func overflows(op string, l, r int64) bool {
+	switch op {
+	case "+":
+		return (r > 0 && l > math.MaxInt64-r) || (r < 0 && l < math.MinInt64-r)
+	case "-":
+		return (r < 0 && l > math.MaxInt64+r) || (r > 0 && l < math.MinInt64+r)
+	case "*":
+		if l == 0 || r == 0 {
+			return false
+		}
+		p := l * r
+		return p/r != l || (l == -1 && r == math.MinInt64) || (r == -1 && l == math.MinInt64)
+	case "/":
+		return l == math.MinInt64 && r == -1
+	}
+	return false
+}
+
+func evalBigInfixExpr(op string, left, right obj.Object) obj.Object {
+	var l, r = toBig(left), toBig(right)
+
+	switch op {
+
+	case "+":
+		return bigtoo(l.Add(l, r))
+
+	case "-":
+		return bigtoo(l.Sub(l, r))
+
+	case "*":
+		return bigtoo(l.Mul(l, r))
+
+	case "/":
+		if r.Sign() == 0 {
+			return newError("division by zero")
+		}
+		// Quo truncates towards zero like the int64 division does.
+		return bigtoo(l.Quo(l, r))
+
+	case "==":
+		return btoo(l.Cmp(r) == 0)
+
+	case "!=":
+		return btoo(l.Cmp(r) != 0)
+
+	case "<", ">", "<=", ">=":
+		return btoo(ordered(op, l.Cmp(r)))
+
+	default:
+		lt := left.Type().String()
+		rt := right.Type().String()
+		return newError("unknown operator: %s %s %s", lt, op, rt)
+	}
+}
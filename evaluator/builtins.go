@@ -1,7 +1,15 @@
 package evaluator
 
-import "github.com/NicoNex/monkey/obj"
+import (
+	"math"
 
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Signature of the builtins that need the interpreter running them.
+type boundFn func(in *Interpreter, args ...obj.Object) obj.Object
+
+// Builtins that don't depend on the interpreter running them.
 var builtins = map[string]*obj.Builtin{
 	"len": &obj.Builtin{
 		Fn: func(args ...obj.Object) obj.Object {
@@ -12,10 +20,13 @@ var builtins = map[string]*obj.Builtin{
 			switch arg := args[0].(type) {
 
 			case *obj.String:
-				return &obj.Integer{Value: int64(len(arg.Value))}
+				return itoo(int64(len(arg.Value)))
 
 			case *obj.Array:
-				return &obj.Integer{Value: int64(len(arg.Elements))}
+				return itoo(int64(len(arg.Elements)))
+
+			case *obj.Hash:
+				return itoo(int64(len(arg.Pairs)))
 
 			default:
 				return newError("len: type not supported, got %s", arg.Type())
@@ -33,10 +44,135 @@ var builtins = map[string]*obj.Builtin{
 				return newError("append: first argument must be an array")
 			}
 
-			if len(args) > 1 {
-				arr.Elements = append(arr.Elements, args[1:]...)
+			// Copy the elements so that the new array never shares its
+			// backing storage with the old one.
+			elements := make([]obj.Object, len(arr.Elements), len(arr.Elements)+len(args)-1)
+			copy(elements, arr.Elements)
+			return &obj.Array{Elements: append(elements, args[1:]...)}
+		},
+	},
+	"push": &obj.Builtin{
+		Fn: func(args ...obj.Object) obj.Object {
+			if len(args) == 0 {
+				return newError("push: no arguments provided")
 			}
+
+			arr, ok := args[0].(*obj.Array)
+			if !ok {
+				return newError("push: first argument must be an array")
+			}
+
+			arr.Elements = append(arr.Elements, args[1:]...)
 			return arr
 		},
 	},
+	"first":   &obj.Builtin{Fn: builtinFirst},
+	"last":    &obj.Builtin{Fn: builtinLast},
+	"rest":    &obj.Builtin{Fn: builtinRest},
+	"slice":   &obj.Builtin{Fn: builtinSlice},
+	"reverse": &obj.Builtin{Fn: builtinReverse},
+	"concat":  &obj.Builtin{Fn: builtinConcat},
+
+	"rune_len":    &obj.Builtin{Fn: builtinRuneLen},
+	"split":       &obj.Builtin{Fn: builtinSplit},
+	"join":        &obj.Builtin{Fn: builtinJoin},
+	"trim":        &obj.Builtin{Fn: builtinTrim},
+	"upper":       &obj.Builtin{Fn: builtinUpper},
+	"lower":       &obj.Builtin{Fn: builtinLower},
+	"replace":     &obj.Builtin{Fn: builtinReplace},
+	"starts_with": &obj.Builtin{Fn: builtinStartsWith},
+	"ends_with":   &obj.Builtin{Fn: builtinEndsWith},
+	"index":       &obj.Builtin{Fn: builtinIndex},
+
+	"error":    &obj.Builtin{Fn: builtinError},
+	"is_error": &obj.Builtin{Fn: builtinIsError},
+
+	"abs":   &obj.Builtin{Fn: builtinAbs},
+	"min":   &obj.Builtin{Fn: builtinMin},
+	"max":   &obj.Builtin{Fn: builtinMax},
+	"clamp": &obj.Builtin{Fn: builtinClamp},
+	"gcd":   &obj.Builtin{Fn: builtinGcd},
+	"float": &obj.Builtin{Fn: builtinFloat},
+	"int":   &obj.Builtin{Fn: builtinInt},
+	"floor": roundFunc("floor", math.Floor),
+	"ceil":  roundFunc("ceil", math.Ceil),
+	"round": roundFunc("round", math.Round),
+	"sqrt":  floatFunc("sqrt", math.Sqrt, nonNegative),
+	"sin":   floatFunc("sin", math.Sin, nil),
+	"cos":   floatFunc("cos", math.Cos, nil),
+	"tan":   floatFunc("tan", math.Tan, nil),
+	"asin":  floatFunc("asin", math.Asin, unitRange),
+	"acos":  floatFunc("acos", math.Acos, unitRange),
+	"atan":  floatFunc("atan", math.Atan, nil),
+	"atan2": &obj.Builtin{Fn: builtinAtan2},
+
+	"json_decode": &obj.Builtin{Fn: builtinJSONDecode},
+
+	"format_time":     &obj.Builtin{Fn: builtinFormatTime},
+	"parse_time":      &obj.Builtin{Fn: builtinParseTime},
+	"duration":        &obj.Builtin{Fn: builtinDuration},
+	"format_duration": &obj.Builtin{Fn: builtinFormatDuration},
+}
+
+// Builtins bound to the interpreter running them.
+var boundBuiltins = map[string]boundFn{
+	"map":      (*Interpreter).builtinMap,
+	"filter":   (*Interpreter).builtinFilter,
+	"reduce":   (*Interpreter).builtinReduce,
+	"sort_by":  (*Interpreter).builtinSortBy,
+	"any":      (*Interpreter).builtinAny,
+	"all":      (*Interpreter).builtinAll,
+	"find":     (*Interpreter).builtinFind,
+	"group_by": (*Interpreter).builtinGroupBy,
+
+	// These can build values of any size, so they check the size limit.
+	"repeat":    (*Interpreter).builtinRepeat,
+	"pad_left":  (*Interpreter).builtinPadLeft,
+	"pad_right": (*Interpreter).builtinPadRight,
+	"pow":       (*Interpreter).builtinPow,
+	"flatten":   (*Interpreter).builtinFlatten,
+
+	// These visit values of any size, so they account for their work.
+	"contains":    (*Interpreter).builtinContains,
+	"index_of":    (*Interpreter).builtinIndexOf,
+	"sum":         (*Interpreter).builtinSum,
+	"json_encode": (*Interpreter).builtinJSONEncode,
+	"format":      (*Interpreter).builtinFormat,
+
+	"puts":    (*Interpreter).builtinPuts,
+	"print":   (*Interpreter).builtinPrint,
+	"println": (*Interpreter).builtinPrintln,
+	"input":   (*Interpreter).builtinInput,
+
+	"rand_int":   (*Interpreter).builtinRandInt,
+	"rand_float": (*Interpreter).builtinRandFloat,
+	"shuffle":    (*Interpreter).builtinShuffle,
+	"choice":     (*Interpreter).builtinChoice,
+
+	"match":      (*Interpreter).builtinMatch,
+	"find_all":   (*Interpreter).builtinFindAll,
+	"replace_re": (*Interpreter).builtinReplaceRe,
+	"split_re":   (*Interpreter).builtinSplitRe,
+
+	"args": (*Interpreter).builtinArgs,
+	"env":  (*Interpreter).builtinEnv,
+	"exit": (*Interpreter).builtinExit,
+
+	"now":   (*Interpreter).builtinNow,
+	"sleep": (*Interpreter).builtinSleep,
+
+	"read_file":  (*Interpreter).builtinReadFile,
+	"read_lines": (*Interpreter).builtinReadLines,
+	"write_file": (*Interpreter).builtinWriteFile,
+	"list_dir":   (*Interpreter).builtinListDir,
+	"exists":     (*Interpreter).builtinExists,
+}
+
+// IsBuiltin reports whether name refers to a builtin function.
+func IsBuiltin(name string) bool {
+	if _, ok := builtins[name]; ok {
+		return true
+	}
+	_, ok := boundBuiltins[name]
+	return ok
 }
@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// HostEnv returns the environment variables of the host called names that
+// are set, for the Env field of the interpreters. The others stay hidden from
+// the scripts.
+func HostEnv(names ...string) map[string]string {
+	var env = make(map[string]string, len(names))
+
+	for _, n := range names {
+		if v, ok := os.LookupEnv(n); ok {
+			env[n] = v
+		}
+	}
+	return env
+}
+
+// args() returns the arguments the script has been run with.
+func (in *Interpreter) builtinArgs(args ...obj.Object) obj.Object {
+	if err := checkArgc("args", args, 0, 0); err != nil {
+		return err
+	}
+	return stringArray(in.Args)
+}
+
+// env(name, default) returns the value of the environment variable name, or
+// default if it's not visible to the script, null if that's missing too.
+func (in *Interpreter) builtinEnv(args ...obj.Object) obj.Object {
+	if err := checkArgc("env", args, 1, 2); err != nil {
+		return err
+	}
+	name, err := stringArg("env", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := in.Env[name]; ok {
+		return stoo(v)
+	}
+	if len(args) == 2 {
+		return args[1]
+	}
+	return NULL
+}
+
+// exit(code) ends the script with the status code, 0 by default, which the
+// host gets with ExitStatus.
+func (in *Interpreter) builtinExit(args ...obj.Object) obj.Object {
+	if err := checkArgc("exit", args, 0, 1); err != nil {
+		return err
+	}
+
+	var code int64
+	if len(args) == 1 {
+		var err obj.Object
+		if code, err = intArg("exit", args, 0); err != nil {
+			return err
+		}
+		if code < 0 || code > 255 {
+			return newError("exit: status out of range: %d", code)
+		}
+	}
+
+	// The exit propagates like an error that can't be caught.
+	in.exit = &obj.Error{Msg: fmt.Sprintf("exit status %d", code), Kind: "exit", Pos: -1, Fatal: true}
+	in.status = int(code)
+	return in.exit
+}
+
+// ExitStatus returns the status code passed to exit if the evaluation that
+// returned o ended because the script called it.
+func (in *Interpreter) ExitStatus(o obj.Object) (int, bool) {
+	if e, ok := o.(*obj.Error); ok && e != nil && e == in.exit {
+		return in.status, true
+	}
+	return 0, false
+}
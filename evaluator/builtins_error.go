@@ -0,0 +1,30 @@
+package evaluator
+
+import "github.com/NicoNex/monkey/obj"
+
+// error(msg, kind) returns an error value with the given message and kind,
+// "error" by default, ready to be thrown.
+func builtinError(args ...obj.Object) obj.Object {
+	if err := checkArgc("error", args, 1, 2); err != nil {
+		return err
+	}
+	msg, err := stringArg("error", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var kind = "error"
+	if len(args) == 2 {
+		if kind, err = stringArg("error", args, 1); err != nil {
+			return err
+		}
+	}
+	return &obj.Error{Msg: msg, Kind: kind, Pos: -1, Caught: true}
+}
+
+func builtinIsError(args ...obj.Object) obj.Object {
+	if err := checkArgc("is_error", args, 1, 1); err != nil {
+		return err
+	}
+	return btoo(args[0].Type() == obj.ERROR)
+}
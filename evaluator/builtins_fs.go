@@ -0,0 +1,149 @@
+package evaluator
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Returns the file system name of the path in the i-th argument of the
+// builtin name, along with the file system of the interpreter.
+func (in *Interpreter) pathArg(name string, args []obj.Object, i int) (fs.FS, string, obj.Object) {
+	p, err := stringArg(name, args, i)
+	if err != nil {
+		return nil, "", err
+	}
+	if in.FS == nil {
+		return nil, "", newError("%s: no file system available", name)
+	}
+
+	fname, ok := fsPath(p)
+	if !ok {
+		return nil, "", newError("%s: path outside the root: %s", name, p)
+	}
+	return in.FS, fname, nil
+}
+
+// read_file(path) returns the content of the file at path.
+func (in *Interpreter) builtinReadFile(args ...obj.Object) obj.Object {
+	if err := checkArgc("read_file", args, 1, 1); err != nil {
+		return err
+	}
+	fsys, name, err := in.pathArg("read_file", args, 0)
+	if err != nil {
+		return err
+	}
+
+	b, e := fs.ReadFile(fsys, name)
+	if e != nil {
+		return newError("read_file: %v", e)
+	}
+	return stoo(string(b))
+}
+
+// read_lines(path) returns the lines of the file at path, without their line
+// terminators.
+func (in *Interpreter) builtinReadLines(args ...obj.Object) obj.Object {
+	if err := checkArgc("read_lines", args, 1, 1); err != nil {
+		return err
+	}
+	fsys, name, err := in.pathArg("read_lines", args, 0)
+	if err != nil {
+		return err
+	}
+
+	b, e := fs.ReadFile(fsys, name)
+	if e != nil {
+		return newError("read_lines: %v", e)
+	}
+
+	var lines []obj.Object
+	s := strings.TrimSuffix(string(b), "\n")
+	if s != "" {
+		for _, l := range strings.Split(s, "\n") {
+			lines = append(lines, stoo(strings.TrimSuffix(l, "\r")))
+		}
+	}
+	return &obj.Array{Elements: lines}
+}
+
+// write_file(path, content) writes the string content to the file at path,
+// replacing it if it exists.
+func (in *Interpreter) builtinWriteFile(args ...obj.Object) obj.Object {
+	if err := checkArgc("write_file", args, 2, 2); err != nil {
+		return err
+	}
+	fsys, name, err := in.pathArg("write_file", args, 0)
+	if err != nil {
+		return err
+	}
+	content, err := stringArg("write_file", args, 1)
+	if err != nil {
+		return err
+	}
+
+	wfs, ok := fsys.(WriteFS)
+	if !ok {
+		return newError("write_file: read-only file system")
+	}
+	if e := wfs.WriteFile(name, []byte(content), 0644); e != nil {
+		return newError("write_file: %v", e)
+	}
+	return NULL
+}
+
+// list_dir(path) returns the sorted names of the entries in the directory at
+// path, the root if it's omitted.
+func (in *Interpreter) builtinListDir(args ...obj.Object) obj.Object {
+	if err := checkArgc("list_dir", args, 0, 1); err != nil {
+		return err
+	}
+
+	var (
+		fsys fs.FS = in.FS
+		name       = "."
+		err  obj.Object
+	)
+	if len(args) == 1 {
+		fsys, name, err = in.pathArg("list_dir", args, 0)
+	} else if fsys == nil {
+		err = newError("list_dir: no file system available")
+	}
+	if err != nil {
+		return err
+	}
+
+	entries, e := fs.ReadDir(fsys, name)
+	if e != nil {
+		return newError("list_dir: %v", e)
+	}
+
+	var names = make([]obj.Object, len(entries))
+	for i, e := range entries {
+		names[i] = stoo(e.Name())
+	}
+	return &obj.Array{Elements: names}
+}
+
+// exists(path) reports whether there's a file or a directory at path.
+func (in *Interpreter) builtinExists(args ...obj.Object) obj.Object {
+	if err := checkArgc("exists", args, 1, 1); err != nil {
+		return err
+	}
+	fsys, name, err := in.pathArg("exists", args, 0)
+	if err != nil {
+		return err
+	}
+
+	_, e := fs.Stat(fsys, name)
+	switch {
+	case e == nil:
+		return TRUE
+	case errors.Is(e, fs.ErrNotExist):
+		return FALSE
+	default:
+		return newError("exists: %v", e)
+	}
+}
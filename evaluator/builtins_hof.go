@@ -0,0 +1,242 @@
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Returns the i-th argument of the builtin name if it can be called.
+func funcArg(name string, args []obj.Object, i int) (obj.Object, obj.Object) {
+	switch args[i].(type) {
+	case *obj.Function, *obj.Builtin:
+		return args[i], nil
+	}
+	return nil, argError(name, i, "a function", args[i])
+}
+
+// Returns the array and the function the higher order builtin name is
+// called with.
+func arrayFuncArgs(name string, args []obj.Object, min, max int) (*obj.Array, obj.Object, obj.Object) {
+	if err := checkArgc(name, args, min, max); err != nil {
+		return nil, nil, err
+	}
+	arr, err := arrayArg(name, args, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fn, err := funcArg(name, args, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return arr, fn, nil
+}
+
+func (in *Interpreter) builtinMap(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("map", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	elements := make([]obj.Object, len(arr.Elements))
+	for i, e := range arr.Elements {
+		val := in.applyFunction(fn, []obj.Object{e})
+		if isError(val) {
+			return val
+		}
+		elements[i] = val
+	}
+	return &obj.Array{Elements: elements}
+}
+
+func (in *Interpreter) builtinFilter(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("filter", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	var elements []obj.Object
+	for _, e := range arr.Elements {
+		val := in.applyFunction(fn, []obj.Object{e})
+		if isError(val) {
+			return val
+		}
+		if isTruthy(val) {
+			elements = append(elements, e)
+		}
+	}
+	return &obj.Array{Elements: elements}
+}
+
+// reduce(arr, fn, init) folds arr from the left starting from init, or from
+// the first element when init is missing.
+func (in *Interpreter) builtinReduce(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("reduce", args, 2, 3)
+	if err != nil {
+		return err
+	}
+
+	var acc obj.Object = NULL
+	var elements = arr.Elements
+
+	if len(args) == 3 {
+		acc = args[2]
+	} else if len(elements) > 0 {
+		acc, elements = elements[0], elements[1:]
+	}
+
+	for _, e := range elements {
+		acc = in.applyFunction(fn, []obj.Object{acc, e})
+		if isError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+// sort_by(arr, fn) returns a copy of arr stably sorted by the keys fn returns
+// for each element, which must be either all integers or all strings.
+func (in *Interpreter) builtinSortBy(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("sort_by", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]obj.Object, len(arr.Elements))
+	for i, e := range arr.Elements {
+		key := in.applyFunction(fn, []obj.Object{e})
+		if isError(key) {
+			return key
+		}
+		if !isInteger(key) && key.Type() != obj.STRING {
+			return newError("sort_by: keys must be integers or strings, got %s", key.Type())
+		}
+		if i > 0 && isInteger(key) != isInteger(keys[0]) {
+			return newError("sort_by: mismatched keys: %s and %s", keys[0].Type(), key.Type())
+		}
+		keys[i] = key
+	}
+
+	idx := make([]int, len(keys))
+	for i := range idx {
+		idx[i] = i
+	}
+	// The sort can't be stopped, so the comparisons made after the limits
+	// are exceeded do nothing.
+	sort.SliceStable(idx, func(i, j int) bool {
+		if err == nil {
+			err = in.charge(1)
+		}
+		return err == nil && less(keys[idx[i]], keys[idx[j]])
+	})
+	if err != nil {
+		return err
+	}
+
+	elements := make([]obj.Object, len(idx))
+	for i, j := range idx {
+		elements[i] = arr.Elements[j]
+	}
+	return &obj.Array{Elements: elements}
+}
+
+// Reports whether a sorts before b, they must be both integers, of any size,
+// or strings.
+func less(a, b obj.Object) bool {
+	if ai, ok := a.(*obj.Integer); ok {
+		if bi, ok := b.(*obj.Integer); ok {
+			return ai.Value < bi.Value
+		}
+	}
+	if isInteger(a) {
+		return toBig(a).Cmp(toBig(b)) < 0
+	}
+	return a.(*obj.String).Value < b.(*obj.String).Value
+}
+
+func (in *Interpreter) builtinAny(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("any", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range arr.Elements {
+		val := in.applyFunction(fn, []obj.Object{e})
+		if isError(val) {
+			return val
+		}
+		if isTruthy(val) {
+			return TRUE
+		}
+	}
+	return FALSE
+}
+
+func (in *Interpreter) builtinAll(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("all", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range arr.Elements {
+		val := in.applyFunction(fn, []obj.Object{e})
+		if isError(val) {
+			return val
+		}
+		if !isTruthy(val) {
+			return FALSE
+		}
+	}
+	return TRUE
+}
+
+// find(arr, fn) returns the first element of arr fn is truthy for, or null.
+func (in *Interpreter) builtinFind(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("find", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range arr.Elements {
+		val := in.applyFunction(fn, []obj.Object{e})
+		if isError(val) {
+			return val
+		}
+		if isTruthy(val) {
+			return e
+		}
+	}
+	return NULL
+}
+
+// group_by(arr, fn) returns a hash mapping each key fn returns to the array
+// of the elements it was returned for, in their original order.
+func (in *Interpreter) builtinGroupBy(args ...obj.Object) obj.Object {
+	arr, fn, err := arrayFuncArgs("group_by", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	pairs := make(map[obj.HashKey]obj.HashPair)
+	for _, e := range arr.Elements {
+		val := in.applyFunction(fn, []obj.Object{e})
+		if isError(val) {
+			return val
+		}
+
+		key, ok := val.(obj.Hashable)
+		if !ok {
+			return newError("group_by: unusable as hash key: %s", val.Type())
+		}
+
+		hk := key.HashKey()
+		group, ok := pairs[hk]
+		if !ok {
+			group = obj.HashPair{Key: key, Value: &obj.Array{}}
+			pairs[hk] = group
+		}
+		g := group.Value.(*obj.Array)
+		g.Elements = append(g.Elements, e)
+	}
+	return &obj.Hash{Pairs: pairs}
+}
@@ -0,0 +1,89 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// puts(args...) writes each argument on its own line.
+func (in *Interpreter) builtinPuts(args ...obj.Object) obj.Object {
+	for _, a := range args {
+		s, err := in.inspect(a)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(in.Stdout, s); err != nil {
+			return newError("puts: %v", err)
+		}
+	}
+	return NULL
+}
+
+// print(args...) writes the arguments separated by spaces.
+func (in *Interpreter) builtinPrint(args ...obj.Object) obj.Object {
+	s, err := in.inspectAll(args)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(in.Stdout, s); err != nil {
+		return newError("print: %v", err)
+	}
+	return NULL
+}
+
+// println(args...) writes the arguments separated by spaces and a newline.
+func (in *Interpreter) builtinPrintln(args ...obj.Object) obj.Object {
+	s, err := in.inspectAll(args)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(in.Stdout, s+"\n"); err != nil {
+		return newError("println: %v", err)
+	}
+	return NULL
+}
+
+// Returns the representations of args separated by spaces.
+func (in *Interpreter) inspectAll(args []obj.Object) (string, obj.Object) {
+	var s = make([]string, len(args))
+
+	for i, a := range args {
+		var err obj.Object
+		if s[i], err = in.inspect(a); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(s, " "), nil
+}
+
+// input(prompt) writes prompt and returns the next line read without the line
+// terminator, or null at the end of the input.
+func (in *Interpreter) builtinInput(args ...obj.Object) obj.Object {
+	if err := checkArgc("input", args, 0, 1); err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		prompt, err := stringArg("input", args, 0)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(in.Stdout, prompt); err != nil {
+			return newError("input: %v", err)
+		}
+	}
+
+	line, err := in.reader().ReadString('\n')
+	switch {
+	case err == io.EOF && line == "":
+		return NULL
+	case err != nil && err != io.EOF:
+		return newError("input: %v", err)
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	return stoo(strings.TrimSuffix(line, "\r"))
+}
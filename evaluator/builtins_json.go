@@ -0,0 +1,204 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Largest indent json_encode accepts.
+const maxIndent = 16
+
+// json_encode(value, indent) returns the JSON encoding of value, indented by
+// indent spaces, or by the indent string, if it's given.
+//
+// Arrays become JSON arrays and hashes with string keys JSON objects, with
+// their keys sorted. Functions, errors and the other values that have no JSON
+// representation can't be encoded.
+func (in *Interpreter) builtinJSONEncode(args ...obj.Object) obj.Object {
+	if err := checkArgc("json_encode", args, 1, 2); err != nil {
+		return err
+	}
+
+	var indent string
+	if len(args) == 2 {
+		switch i := args[1].(type) {
+		case *obj.Integer:
+			if i.Value < 0 {
+				return newError("json_encode: negative indent: %d", i.Value)
+			}
+			if i.Value > maxIndent {
+				return newError("json_encode: indent too large: %d, want at most %d", i.Value, maxIndent)
+			}
+			indent = strings.Repeat(" ", int(i.Value))
+		case *obj.String:
+			indent = i.Value
+		default:
+			return argError("json_encode", 1, "an integer or a string", args[1])
+		}
+	}
+
+	v, err := in.toJSON(args[0], nil)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return newError("json_encode: %v", err)
+	}
+	return stoo(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// Converts o to the value encoding/json encodes the same way. The arrays
+// being converted are in path, to detect those that contain themselves.
+func (in *Interpreter) toJSON(o obj.Object, path []*obj.Array) (interface{}, obj.Object) {
+	if err := in.charge(1); err != nil {
+		return nil, err
+	}
+
+	switch o := o.(type) {
+	case *obj.Null:
+		return nil, nil
+
+	case *obj.Boolean:
+		return o.Value, nil
+
+	case *obj.String:
+		return o.Value, nil
+
+	case *obj.Integer, *obj.BigInt:
+		return json.Number(o.Inspect()), nil
+
+	case *obj.Float:
+		if math.IsInf(o.Value, 0) || math.IsNaN(o.Value) {
+			return nil, newError("json_encode: unsupported value: %s", o.Inspect())
+		}
+		return json.Number(o.Inspect()), nil
+
+	case *obj.Array:
+		for _, a := range path {
+			if a == o {
+				return nil, newError("json_encode: array contains itself")
+			}
+		}
+		path = append(path, o)
+
+		var ret = make([]interface{}, len(o.Elements))
+		for i, e := range o.Elements {
+			v, err := in.toJSON(e, path)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = v
+		}
+		return ret, nil
+
+	case *obj.Hash:
+		var ret = make(map[string]interface{}, len(o.Pairs))
+
+		for _, p := range o.Pairs {
+			k, ok := p.Key.(*obj.String)
+			if !ok {
+				return nil, newError("json_encode: object keys must be strings, got %s", p.Key.Type())
+			}
+			v, err := in.toJSON(p.Value, path)
+			if err != nil {
+				return nil, err
+			}
+			ret[k.Value] = v
+		}
+		return ret, nil
+
+	default:
+		return nil, newError("json_encode: cannot encode %s", o.Type())
+	}
+}
+
+// json_decode(s) returns the value encoded in the JSON string s. Objects are
+// decoded as hashes and numbers as integers, unless they have a fraction or
+// an exponent.
+func builtinJSONDecode(args ...obj.Object) obj.Object {
+	if err := checkArgc("json_decode", args, 1, 1); err != nil {
+		return err
+	}
+	s, err := stringArg("json_decode", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return newError("json_decode: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return newError("json_decode: unexpected data after the value")
+	}
+	return fromJSON(v)
+}
+
+// Converts the value v decoded by encoding/json to an object.
+func fromJSON(v interface{}) obj.Object {
+	switch v := v.(type) {
+	case bool:
+		return btoo(v)
+
+	case string:
+		return stoo(v)
+
+	case json.Number:
+		return jsonNumber(string(v))
+
+	case []interface{}:
+		var elements = make([]obj.Object, len(v))
+		for i, e := range v {
+			if elements[i] = fromJSON(e); isError(elements[i]) {
+				return elements[i]
+			}
+		}
+		return &obj.Array{Elements: elements}
+
+	case map[string]interface{}:
+		var pairs = make(map[obj.HashKey]obj.HashPair, len(v))
+		for k, e := range v {
+			val := fromJSON(e)
+			if isError(val) {
+				return val
+			}
+			key := stoo(k)
+			pairs[key.HashKey()] = obj.HashPair{Key: key, Value: val}
+		}
+		return &obj.Hash{Pairs: pairs}
+
+	default:
+		return NULL
+	}
+}
+
+// Returns the number represented by the JSON number n.
+func jsonNumber(n string) obj.Object {
+	if strings.ContainsAny(n, ".eE") {
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return newError("json_decode: number out of range: %s", n)
+		}
+		return ftoo(f)
+	}
+
+	if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+		return itoo(i)
+	}
+	b, _ := new(big.Int).SetString(n, 10)
+	return bigtoo(b)
+}
@@ -0,0 +1,279 @@
+package evaluator
+
+import "github.com/NicoNex/monkey/obj"
+
+var ordinals = []string{"first", "second", "third", "fourth"}
+
+// Returns an error if the number of arguments passed to the builtin name is
+// not between min and max, a negative max means there's no upper limit.
+func checkArgc(name string, args []obj.Object, min, max int) obj.Object {
+	var l = len(args)
+
+	switch {
+	case l >= min && (l <= max || max < 0):
+		return nil
+	case min == max:
+		return newError("%s: wrong number of arguments: got %d, want %d", name, l, min)
+	case max < 0:
+		return newError("%s: wrong number of arguments: got %d, want at least %d", name, l, min)
+	default:
+		return newError("%s: wrong number of arguments: got %d, want %d to %d", name, l, min, max)
+	}
+}
+
+// Returns an error reporting that the i-th argument of the builtin name is not
+// of the type typ.
+func argError(name string, i int, typ string, arg obj.Object) obj.Object {
+	return newError("%s: %s argument must be %s, got %s", name, ordinals[i], typ, arg.Type())
+}
+
+// Returns the i-th argument of the builtin name if it's an array.
+func arrayArg(name string, args []obj.Object, i int) (*obj.Array, obj.Object) {
+	if arr, ok := args[i].(*obj.Array); ok {
+		return arr, nil
+	}
+	return nil, argError(name, i, "an array", args[i])
+}
+
+// Returns the i-th argument of the builtin name if it's an integer.
+func intArg(name string, args []obj.Object, i int) (int64, obj.Object) {
+	switch n := args[i].(type) {
+	case *obj.Integer:
+		return n.Value, nil
+	case *obj.BigInt:
+		return 0, newError("%s: %s argument out of range: %s", name, ordinals[i], n.Inspect())
+	}
+	return 0, argError(name, i, "an integer", args[i])
+}
+
+// Converts the possibly negative index i of a sequence of length l into an
+// absolute one, the result is out of range if i is.
+func absIndex(i int64, l int) int64 {
+	if i < 0 {
+		return i + int64(l)
+	}
+	return i
+}
+
+func builtinFirst(args ...obj.Object) obj.Object {
+	if err := checkArgc("first", args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("first", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(arr.Elements) == 0 {
+		return NULL
+	}
+	return arr.Elements[0]
+}
+
+func builtinLast(args ...obj.Object) obj.Object {
+	if err := checkArgc("last", args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("last", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if l := len(arr.Elements); l > 0 {
+		return arr.Elements[l-1]
+	}
+	return NULL
+}
+
+func builtinRest(args ...obj.Object) obj.Object {
+	if err := checkArgc("rest", args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("rest", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(arr.Elements) == 0 {
+		return NULL
+	}
+	return &obj.Array{Elements: copyObjects(arr.Elements[1:])}
+}
+
+// slice(arr, start, end) returns the elements of arr from start up to end,
+// excluded. Negative indices count from the end and end defaults to the
+// length of the array, out of range bounds return null.
+func builtinSlice(args ...obj.Object) obj.Object {
+	if err := checkArgc("slice", args, 2, 3); err != nil {
+		return err
+	}
+	arr, err := arrayArg("slice", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var l = len(arr.Elements)
+	start, err := intArg("slice", args, 1)
+	if err != nil {
+		return err
+	}
+	end := int64(l)
+	if len(args) == 3 {
+		if end, err = intArg("slice", args, 2); err != nil {
+			return err
+		}
+	}
+
+	start, end = absIndex(start, l), absIndex(end, l)
+	if start < 0 || end > int64(l) || start > end {
+		return NULL
+	}
+	return &obj.Array{Elements: copyObjects(arr.Elements[start:end])}
+}
+
+func builtinReverse(args ...obj.Object) obj.Object {
+	if err := checkArgc("reverse", args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("reverse", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var l = len(arr.Elements)
+	var elements = make([]obj.Object, l)
+	for i, e := range arr.Elements {
+		elements[l-i-1] = e
+	}
+	return &obj.Array{Elements: elements}
+}
+
+func (in *Interpreter) builtinContains(args ...obj.Object) obj.Object {
+	if err := checkArgc("contains", args, 2, 2); err != nil {
+		return err
+	}
+	arr, err := arrayArg("contains", args, 0)
+	if err != nil {
+		return err
+	}
+
+	i, err := in.indexOf(arr, args[1])
+	if err != nil {
+		return err
+	}
+	return btoo(i >= 0)
+}
+
+func (in *Interpreter) builtinIndexOf(args ...obj.Object) obj.Object {
+	if err := checkArgc("index_of", args, 2, 2); err != nil {
+		return err
+	}
+	arr, err := arrayArg("index_of", args, 0)
+	if err != nil {
+		return err
+	}
+
+	i, err := in.indexOf(arr, args[1])
+	if err != nil {
+		return err
+	}
+	return itoo(int64(i))
+}
+
+// Returns the index of the first element of arr equal to o, or -1.
+func (in *Interpreter) indexOf(arr *obj.Array, o obj.Object) (int, obj.Object) {
+	for i, e := range arr.Elements {
+		if err := in.charge(1); err != nil {
+			return 0, err
+		}
+		eq, err := in.equal(e, o)
+		if err != nil {
+			return 0, err
+		}
+		if eq {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// concat(arrays...) returns a new array with the elements of all the arrays.
+func builtinConcat(args ...obj.Object) obj.Object {
+	var elements = []obj.Object{}
+
+	for i := range args {
+		arr, ok := args[i].(*obj.Array)
+		if !ok {
+			return newError("concat: argument %d must be an array, got %s", i+1, args[i].Type())
+		}
+		elements = append(elements, arr.Elements...)
+	}
+	return &obj.Array{Elements: elements}
+}
+
+// flatten(arr, depth) replaces the arrays nested in arr with their elements,
+// up to depth levels deep or all of them if depth is omitted.
+func (in *Interpreter) builtinFlatten(args ...obj.Object) obj.Object {
+	if err := checkArgc("flatten", args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := arrayArg("flatten", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var depth int64 = -1
+	if len(args) == 2 {
+		if depth, err = intArg("flatten", args, 1); err != nil {
+			return err
+		}
+	}
+
+	elements, err := in.flatten([]obj.Object{}, arr, depth, nil)
+	if err != nil {
+		return err
+	}
+	return &obj.Array{Elements: elements}
+}
+
+// Appends the elements of arr to dst, flattening the nested arrays up to
+// depth levels deep. The arrays being flattened are in path, to detect those
+// that contain themselves and would be flattened forever with no depth.
+func (in *Interpreter) flatten(dst []obj.Object, arr *obj.Array, depth int64, path []*obj.Array) ([]obj.Object, obj.Object) {
+	if depth < 0 {
+		for _, a := range path {
+			if a == arr {
+				return nil, newError("flatten: array contains itself")
+			}
+		}
+		path = append(path, arr)
+	}
+
+	for _, e := range arr.Elements {
+		if err := in.charge(1); err != nil {
+			return nil, err
+		}
+		if a, ok := e.(*obj.Array); ok && depth != 0 {
+			var err obj.Object
+			if dst, err = in.flatten(dst, a, depth-1, path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Arrays sharing their elements can flatten to a result much
+		// larger than them, so the size is checked while it grows.
+		if in.tooLarge(int64(len(dst)+1), 1) {
+			return nil, limitError("size limit exceeded: %d", in.limits.MaxLen)
+		}
+		dst = append(dst, e)
+	}
+	return dst, nil
+}
+
+// Returns a copy of elements that doesn't share its backing storage.
+func copyObjects(elements []obj.Object) []obj.Object {
+	var ret = make([]obj.Object, len(elements))
+	copy(ret, elements)
+	return ret
+}
@@ -0,0 +1,303 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Returns the i-th argument of the builtin name if it's a number.
+func numberArg(name string, args []obj.Object, i int) (obj.Object, obj.Object) {
+	if isNumber(args[i]) {
+		return args[i], nil
+	}
+	return nil, argError(name, i, "a number", args[i])
+}
+
+// Returns an error reporting that arg is outside the domain of the builtin
+// name.
+func domainError(name string, arg obj.Object) obj.Object {
+	return newError("%s: argument out of domain: %s", name, arg.Inspect())
+}
+
+// Returns a builtin that applies fn to its only argument converted to a
+// float, the arguments for which valid returns false are out of its domain.
+func floatFunc(name string, fn func(float64) float64, valid func(float64) bool) *obj.Builtin {
+	return &obj.Builtin{
+		Fn: func(args ...obj.Object) obj.Object {
+			if err := checkArgc(name, args, 1, 1); err != nil {
+				return err
+			}
+			n, err := numberArg(name, args, 0)
+			if err != nil {
+				return err
+			}
+
+			f := toFloat(n)
+			if valid != nil && !valid(f) {
+				return domainError(name, n)
+			}
+			return ftoo(fn(f))
+		},
+	}
+}
+
+// Returns a builtin that rounds its only argument to an integer with fn.
+func roundFunc(name string, fn func(float64) float64) *obj.Builtin {
+	return &obj.Builtin{
+		Fn: func(args ...obj.Object) obj.Object {
+			if err := checkArgc(name, args, 1, 1); err != nil {
+				return err
+			}
+			n, err := numberArg(name, args, 0)
+			if err != nil {
+				return err
+			}
+
+			if f, ok := n.(*obj.Float); ok {
+				return ftoi(name, fn(f.Value))
+			}
+			return n
+		},
+	}
+}
+
+// Converts the integral float f to an integer, failing if f is infinite or
+// not a number.
+func ftoi(name string, f float64) obj.Object {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return newError("%s: cannot convert %s to an integer", name, ftoo(f).Inspect())
+	}
+	if f >= math.MinInt64 && f < math.MaxInt64 {
+		return itoo(int64(f))
+	}
+	b, _ := big.NewFloat(f).Int(nil)
+	return bigtoo(b)
+}
+
+// Returns the numbers min and max choose from: the arguments, or the
+// elements of the array if that's the only argument.
+func numbers(name string, args []obj.Object) ([]obj.Object, obj.Object) {
+	if err := checkArgc(name, args, 1, -1); err != nil {
+		return nil, err
+	}
+
+	var nums = args
+	if arr, ok := args[0].(*obj.Array); ok && len(args) == 1 {
+		if len(arr.Elements) == 0 {
+			return nil, newError("%s: empty array", name)
+		}
+		nums = arr.Elements
+	}
+
+	for _, n := range nums {
+		if !isNumber(n) {
+			return nil, newError("%s: arguments must be numbers, got %s", name, n.Type())
+		}
+	}
+	return nums, nil
+}
+
+// Returns the number in nums that compares with the others as want.
+func extreme(nums []obj.Object, want int) obj.Object {
+	var ret = nums[0]
+
+	for _, n := range nums[1:] {
+		if c, _ := compare("<", n, ret); c == want {
+			ret = n
+		}
+	}
+	return ret
+}
+
+// min(a, b, ...) returns the smallest of its arguments, or of the elements
+// of the array if it's the only one.
+func builtinMin(args ...obj.Object) obj.Object {
+	nums, err := numbers("min", args)
+	if err != nil {
+		return err
+	}
+	return extreme(nums, -1)
+}
+
+// max(a, b, ...) returns the largest of its arguments, or of the elements of
+// the array if it's the only one.
+func builtinMax(args ...obj.Object) obj.Object {
+	nums, err := numbers("max", args)
+	if err != nil {
+		return err
+	}
+	return extreme(nums, 1)
+}
+
+func builtinAbs(args ...obj.Object) obj.Object {
+	if err := checkArgc("abs", args, 1, 1); err != nil {
+		return err
+	}
+	n, err := numberArg("abs", args, 0)
+	if err != nil {
+		return err
+	}
+
+	switch n := n.(type) {
+	case *obj.Float:
+		return ftoo(math.Abs(n.Value))
+	case *obj.BigInt:
+		return bigtoo(new(big.Int).Abs(n.Value))
+	default:
+		if n.(*obj.Integer).Value < 0 {
+			return evalPrefixMinusOpExpr(n)
+		}
+		return n
+	}
+}
+
+// pow(base, exp) raises base to exp. The result is an integer if both are
+// integers and exp is not negative, a float otherwise.
+func (in *Interpreter) builtinPow(args ...obj.Object) obj.Object {
+	if err := checkArgc("pow", args, 2, 2); err != nil {
+		return err
+	}
+	base, err := numberArg("pow", args, 0)
+	if err != nil {
+		return err
+	}
+	exp, err := numberArg("pow", args, 1)
+	if err != nil {
+		return err
+	}
+
+	if isInteger(base) && isInteger(exp) && toBig(exp).Sign() >= 0 {
+		e, err := intArg("pow", args, 1)
+		if err != nil {
+			return err
+		}
+		if in.powTooLarge(toBig(base), e) {
+			return limitError("size limit exceeded: %d", in.limits.MaxLen)
+		}
+		return bigtoo(new(big.Int).Exp(toBig(base), big.NewInt(e), nil))
+	}
+
+	b, e := toFloat(base), toFloat(exp)
+	switch {
+	case b == 0 && e < 0:
+		return newError("division by zero")
+	case b < 0 && e != math.Trunc(e):
+		return domainError("pow", base)
+	}
+	return ftoo(math.Pow(b, e))
+}
+
+// clamp(x, lo, hi) returns x limited to the range from lo to hi.
+func builtinClamp(args ...obj.Object) obj.Object {
+	if err := checkArgc("clamp", args, 3, 3); err != nil {
+		return err
+	}
+	for i := range args {
+		if _, err := numberArg("clamp", args, i); err != nil {
+			return err
+		}
+	}
+
+	x, lo, hi := args[0], args[1], args[2]
+	if c, _ := compare(">", lo, hi); c > 0 {
+		return newError("clamp: lower bound %s greater than upper bound %s", lo.Inspect(), hi.Inspect())
+	}
+	if c, _ := compare("<", x, lo); c < 0 {
+		return lo
+	}
+	if c, _ := compare(">", x, hi); c > 0 {
+		return hi
+	}
+	return x
+}
+
+// gcd(a, b) returns the greatest common divisor of the integers a and b,
+// which is never negative.
+func builtinGcd(args ...obj.Object) obj.Object {
+	if err := checkArgc("gcd", args, 2, 2); err != nil {
+		return err
+	}
+	for i := range args {
+		if !isInteger(args[i]) {
+			return argError("gcd", i, "an integer", args[i])
+		}
+	}
+	return bigtoo(new(big.Int).GCD(nil, nil, toBig(args[0]), toBig(args[1])))
+}
+
+// sum(arr) returns the sum of the numbers in arr, 0 if it's empty.
+func (in *Interpreter) builtinSum(args ...obj.Object) obj.Object {
+	if err := checkArgc("sum", args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("sum", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var ret obj.Object = itoo(0)
+	for _, e := range arr.Elements {
+		if err := in.charge(1); err != nil {
+			return err
+		}
+		if !isNumber(e) {
+			return newError("sum: elements must be numbers, got %s", e.Type())
+		}
+		ret = in.evalInfixExpr("+", ret, e)
+	}
+	return ret
+}
+
+func builtinAtan2(args ...obj.Object) obj.Object {
+	if err := checkArgc("atan2", args, 2, 2); err != nil {
+		return err
+	}
+	y, err := numberArg("atan2", args, 0)
+	if err != nil {
+		return err
+	}
+	x, err := numberArg("atan2", args, 1)
+	if err != nil {
+		return err
+	}
+	return ftoo(math.Atan2(toFloat(y), toFloat(x)))
+}
+
+// float(n) converts the number n to a float.
+func builtinFloat(args ...obj.Object) obj.Object {
+	if err := checkArgc("float", args, 1, 1); err != nil {
+		return err
+	}
+	n, err := numberArg("float", args, 0)
+	if err != nil {
+		return err
+	}
+	return ftoo(toFloat(n))
+}
+
+// int(n) converts the number n to an integer, truncating it towards zero.
+func builtinInt(args ...obj.Object) obj.Object {
+	if err := checkArgc("int", args, 1, 1); err != nil {
+		return err
+	}
+	n, err := numberArg("int", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if f, ok := n.(*obj.Float); ok {
+		return ftoi("int", math.Trunc(f.Value))
+	}
+	return n
+}
+
+func nonNegative(f float64) bool {
+	return f >= 0
+}
+
+// Reports whether f is between -1 and 1, the domain of asin and acos.
+func unitRange(f float64) bool {
+	return f >= -1 && f <= 1
+}
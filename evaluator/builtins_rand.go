@@ -0,0 +1,84 @@
+package evaluator
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Seed resets the random number generator used by the random builtins so
+// that they produce the same sequence for the same seed.
+func (in *Interpreter) Seed(seed int64) {
+	in.rand = rand.New(rand.NewSource(seed))
+}
+
+// rand_int(lo, hi) returns a random integer between lo, included, and hi,
+// excluded.
+func (in *Interpreter) builtinRandInt(args ...obj.Object) obj.Object {
+	if err := checkArgc("rand_int", args, 2, 2); err != nil {
+		return err
+	}
+	lo, err := intArg("rand_int", args, 0)
+	if err != nil {
+		return err
+	}
+	hi, err := intArg("rand_int", args, 1)
+	if err != nil {
+		return err
+	}
+	if hi <= lo {
+		return newError("rand_int: empty range from %d to %d", lo, hi)
+	}
+
+	// The difference can overflow an int64 but never an uint64.
+	n := uint64(hi) - uint64(lo)
+	if n <= math.MaxInt64 {
+		return itoo(lo + in.rand.Int63n(int64(n)))
+	}
+	for {
+		if r := in.rand.Uint64(); r < n {
+			return itoo(int64(uint64(lo) + r))
+		}
+	}
+}
+
+// rand_float() returns a random float between 0, included, and 1, excluded.
+func (in *Interpreter) builtinRandFloat(args ...obj.Object) obj.Object {
+	if err := checkArgc("rand_float", args, 0, 0); err != nil {
+		return err
+	}
+	return ftoo(in.rand.Float64())
+}
+
+// shuffle(arr) returns a new array with the elements of arr in random order.
+func (in *Interpreter) builtinShuffle(args ...obj.Object) obj.Object {
+	if err := checkArgc("shuffle", args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("shuffle", args, 0)
+	if err != nil {
+		return err
+	}
+
+	elements := copyObjects(arr.Elements)
+	in.rand.Shuffle(len(elements), func(i, j int) {
+		elements[i], elements[j] = elements[j], elements[i]
+	})
+	return &obj.Array{Elements: elements}
+}
+
+// choice(arr) returns a random element of arr.
+func (in *Interpreter) builtinChoice(args ...obj.Object) obj.Object {
+	if err := checkArgc("choice", args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := arrayArg("choice", args, 0)
+	if err != nil {
+		return err
+	}
+	if len(arr.Elements) == 0 {
+		return newError("choice: empty array")
+	}
+	return arr.Elements[in.rand.Intn(len(arr.Elements))]
+}
@@ -0,0 +1,205 @@
+package evaluator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Number of compiled regular expressions an interpreter keeps, the cache is
+// emptied when it's full so that patterns built at runtime can't grow it
+// forever.
+const maxRegexps = 256
+
+// Returns the regular expression compiled from the i-th argument of the
+// builtin name, which must be a string.
+func (in *Interpreter) regexpArg(name string, args []obj.Object, i int) (*regexp.Regexp, obj.Object) {
+	expr, err := stringArg(name, args, i)
+	if err != nil {
+		return nil, err
+	}
+	if re, ok := in.regexps[expr]; ok {
+		return re, nil
+	}
+
+	re, e := regexp.Compile(expr)
+	if e != nil {
+		return nil, newError("%s: %v", name, e)
+	}
+	if in.regexps == nil || len(in.regexps) >= maxRegexps {
+		in.regexps = make(map[string]*regexp.Regexp)
+	}
+	in.regexps[expr] = re
+	return re, nil
+}
+
+// Returns the regular expression and the string the builtin name is called
+// with, and its optional third argument n, -1 if it's missing.
+func (in *Interpreter) regexpArgs(name string, args []obj.Object) (*regexp.Regexp, string, int, obj.Object) {
+	if err := checkArgc(name, args, 2, 3); err != nil {
+		return nil, "", 0, err
+	}
+	re, err := in.regexpArg(name, args, 0)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	s, err := stringArg(name, args, 1)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var n int64 = -1
+	if len(args) == 3 {
+		if n, err = intArg(name, args, 2); err != nil {
+			return nil, "", 0, err
+		}
+	}
+	return re, s, int(n), nil
+}
+
+// Returns the groups of the match of re in s at loc: a hash from the names
+// of the groups to their text if re has named groups, an array holding the
+// whole match followed by the groups otherwise. The groups that didn't
+// participate in the match are null.
+func groups(re *regexp.Regexp, s string, loc []int) obj.Object {
+	var group = func(i int) obj.Object {
+		if loc[2*i] < 0 {
+			return NULL
+		}
+		return stoo(s[loc[2*i]:loc[2*i+1]])
+	}
+
+	names := re.SubexpNames()
+	if hasNames(names) {
+		var pairs = make(map[obj.HashKey]obj.HashPair)
+		for i, n := range names {
+			if n != "" {
+				key := stoo(n)
+				pairs[key.HashKey()] = obj.HashPair{Key: key, Value: group(i)}
+			}
+		}
+		return &obj.Hash{Pairs: pairs}
+	}
+
+	var elements = make([]obj.Object, len(names))
+	for i := range elements {
+		elements[i] = group(i)
+	}
+	return &obj.Array{Elements: elements}
+}
+
+// Reports whether any of the groups names is not empty.
+func hasNames(names []string) bool {
+	for _, n := range names {
+		if n != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the match of re in s at loc as find_all and replace_re see it: its
+// text if re has no groups, its groups otherwise.
+func found(re *regexp.Regexp, s string, loc []int) obj.Object {
+	if re.NumSubexp() == 0 {
+		return stoo(s[loc[0]:loc[1]])
+	}
+	return groups(re, s, loc)
+}
+
+// match(re, s) returns the groups of the leftmost match of the regular
+// expression re in s, or null if there's none. The groups are a hash if re
+// has named groups, an array starting with the whole match otherwise.
+func (in *Interpreter) builtinMatch(args ...obj.Object) obj.Object {
+	if err := checkArgc("match", args, 2, 2); err != nil {
+		return err
+	}
+	re, err := in.regexpArg("match", args, 0)
+	if err != nil {
+		return err
+	}
+	s, err := stringArg("match", args, 1)
+	if err != nil {
+		return err
+	}
+
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return NULL
+	}
+	return groups(re, s, loc)
+}
+
+// find_all(re, s, n) returns the first n matches of the regular expression
+// re in s, all of them if n is missing. Each match is a string if re has no
+// groups, its groups as match returns them otherwise.
+func (in *Interpreter) builtinFindAll(args ...obj.Object) obj.Object {
+	re, s, n, err := in.regexpArgs("find_all", args)
+	if err != nil {
+		return err
+	}
+
+	locs := re.FindAllStringSubmatchIndex(s, n)
+	elements := make([]obj.Object, len(locs))
+	for i, loc := range locs {
+		elements[i] = found(re, s, loc)
+	}
+	return &obj.Array{Elements: elements}
+}
+
+// replace_re(re, s, repl) returns a copy of s with the matches of the
+// regular expression re replaced by repl. If repl is a string, $1 or ${name}
+// in it stand for the text of the groups. If it's a function, it's called
+// with each match as find_all returns it and must return its replacement.
+func (in *Interpreter) builtinReplaceRe(args ...obj.Object) obj.Object {
+	if err := checkArgc("replace_re", args, 3, 3); err != nil {
+		return err
+	}
+	re, err := in.regexpArg("replace_re", args, 0)
+	if err != nil {
+		return err
+	}
+	s, err := stringArg("replace_re", args, 1)
+	if err != nil {
+		return err
+	}
+
+	switch repl := args[2].(type) {
+	case *obj.String:
+		return stoo(re.ReplaceAllString(s, repl.Value))
+
+	case *obj.Function, *obj.Builtin:
+		var b strings.Builder
+		var last int
+
+		for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+			val := in.applyFunction(repl, []obj.Object{found(re, s, loc)})
+			if isError(val) {
+				return val
+			}
+			str, ok := val.(*obj.String)
+			if !ok {
+				return newError("replace_re: replacement must be a string, got %s", val.Type())
+			}
+			b.WriteString(s[last:loc[0]])
+			b.WriteString(str.Value)
+			last = loc[1]
+		}
+		b.WriteString(s[last:])
+		return stoo(b.String())
+
+	default:
+		return argError("replace_re", 2, "a string or a function", args[2])
+	}
+}
+
+// split_re(re, s, n) splits s around the matches of the regular expression
+// re into at most n substrings, all of them if n is missing.
+func (in *Interpreter) builtinSplitRe(args ...obj.Object) obj.Object {
+	re, s, n, err := in.regexpArgs("split_re", args)
+	if err != nil {
+		return err
+	}
+	return stringArray(re.Split(s, n))
+}
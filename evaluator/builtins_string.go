@@ -0,0 +1,389 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Length in bytes of the longest string the builtins build, allocating
+// more would crash the interpreter or exhaust the memory.
+const maxStrLen = 1 << 30
+
+// Reports whether n copies of a string of size bytes, plus extra bytes, are
+// longer than maxStrLen.
+func tooLong(n int64, size, extra int) bool {
+	return n > 0 && int64(size) > (maxStrLen-int64(extra))/n
+}
+
+// Returns the i-th argument of the builtin name if it's a string.
+func stringArg(name string, args []obj.Object, i int) (string, obj.Object) {
+	if s, ok := args[i].(*obj.String); ok {
+		return s.Value, nil
+	}
+	return "", argError(name, i, "a string", args[i])
+}
+
+// Returns an array holding the strings in s.
+func stringArray(s []string) *obj.Array {
+	var elements = make([]obj.Object, len(s))
+
+	for i, e := range s {
+		elements[i] = stoo(e)
+	}
+	return &obj.Array{Elements: elements}
+}
+
+func builtinRuneLen(args ...obj.Object) obj.Object {
+	if err := checkArgc("rune_len", args, 1, 1); err != nil {
+		return err
+	}
+	s, err := stringArg("rune_len", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return itoo(int64(utf8.RuneCountInString(s)))
+}
+
+// split(s, sep) splits s around each occurrence of sep, around runs of white
+// space if sep is omitted or into its characters if sep is empty.
+func builtinSplit(args ...obj.Object) obj.Object {
+	if err := checkArgc("split", args, 1, 2); err != nil {
+		return err
+	}
+	s, err := stringArg("split", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		return stringArray(strings.Fields(s))
+	}
+	sep, err := stringArg("split", args, 1)
+	if err != nil {
+		return err
+	}
+	return stringArray(strings.Split(s, sep))
+}
+
+// join(arr, sep) concatenates the strings in arr placing sep between them.
+func builtinJoin(args ...obj.Object) obj.Object {
+	if err := checkArgc("join", args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := arrayArg("join", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var sep string
+	if len(args) == 2 {
+		if sep, err = stringArg("join", args, 1); err != nil {
+			return err
+		}
+	}
+
+	var elements = make([]string, len(arr.Elements))
+	for i, e := range arr.Elements {
+		s, ok := e.(*obj.String)
+		if !ok {
+			return newError("join: element %d must be a string, got %s", i, e.Type())
+		}
+		elements[i] = s.Value
+	}
+	return stoo(strings.Join(elements, sep))
+}
+
+// trim(s, cutset) removes the leading and trailing characters of s contained
+// in cutset, or the white space if cutset is omitted.
+func builtinTrim(args ...obj.Object) obj.Object {
+	if err := checkArgc("trim", args, 1, 2); err != nil {
+		return err
+	}
+	s, err := stringArg("trim", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		return stoo(strings.TrimSpace(s))
+	}
+	cutset, err := stringArg("trim", args, 1)
+	if err != nil {
+		return err
+	}
+	return stoo(strings.Trim(s, cutset))
+}
+
+func builtinUpper(args ...obj.Object) obj.Object {
+	if err := checkArgc("upper", args, 1, 1); err != nil {
+		return err
+	}
+	s, err := stringArg("upper", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return stoo(strings.ToUpper(s))
+}
+
+func builtinLower(args ...obj.Object) obj.Object {
+	if err := checkArgc("lower", args, 1, 1); err != nil {
+		return err
+	}
+	s, err := stringArg("lower", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return stoo(strings.ToLower(s))
+}
+
+// replace(s, old, new, n) replaces the first n occurrences of old in s with
+// new, all of them if n is omitted or negative.
+func builtinReplace(args ...obj.Object) obj.Object {
+	if err := checkArgc("replace", args, 3, 4); err != nil {
+		return err
+	}
+
+	var strs [3]string
+	for i := range strs {
+		s, err := stringArg("replace", args, i)
+		if err != nil {
+			return err
+		}
+		strs[i] = s
+	}
+
+	var n int64 = -1
+	if len(args) == 4 {
+		var err obj.Object
+		if n, err = intArg("replace", args, 3); err != nil {
+			return err
+		}
+	}
+	return stoo(strings.Replace(strs[0], strs[1], strs[2], int(n)))
+}
+
+func builtinStartsWith(args ...obj.Object) obj.Object {
+	s, prefix, err := twoStringArgs("starts_with", args)
+	if err != nil {
+		return err
+	}
+	return btoo(strings.HasPrefix(s, prefix))
+}
+
+func builtinEndsWith(args ...obj.Object) obj.Object {
+	s, suffix, err := twoStringArgs("ends_with", args)
+	if err != nil {
+		return err
+	}
+	return btoo(strings.HasSuffix(s, suffix))
+}
+
+// Returns the two strings the builtin name is called with.
+func twoStringArgs(name string, args []obj.Object) (string, string, obj.Object) {
+	if err := checkArgc(name, args, 2, 2); err != nil {
+		return "", "", err
+	}
+	a, err := stringArg(name, args, 0)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := stringArg(name, args, 1)
+	if err != nil {
+		return "", "", err
+	}
+	return a, b, nil
+}
+
+// index(s, sub) returns the index in characters of the first occurrence of
+// sub in s, or -1.
+func builtinIndex(args ...obj.Object) obj.Object {
+	s, sub, err := twoStringArgs("index", args)
+	if err != nil {
+		return err
+	}
+
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return itoo(-1)
+	}
+	return itoo(int64(utf8.RuneCountInString(s[:i])))
+}
+
+func (in *Interpreter) builtinRepeat(args ...obj.Object) obj.Object {
+	if err := checkArgc("repeat", args, 2, 2); err != nil {
+		return err
+	}
+	s, err := stringArg("repeat", args, 0)
+	if err != nil {
+		return err
+	}
+	n, err := intArg("repeat", args, 1)
+	if err != nil {
+		return err
+	}
+
+	if n < 0 {
+		return newError("repeat: negative count: %d", n)
+	}
+	if in.tooLarge(n, len(s)) {
+		return limitError("size limit exceeded: %d", in.limits.MaxLen)
+	}
+	if tooLong(n, len(s), 0) {
+		return newError("repeat: result too long")
+	}
+	return stoo(strings.Repeat(s, int(n)))
+}
+
+func (in *Interpreter) builtinPadLeft(args ...obj.Object) obj.Object {
+	return in.pad("pad_left", args, true)
+}
+
+func (in *Interpreter) builtinPadRight(args ...obj.Object) obj.Object {
+	return in.pad("pad_right", args, false)
+}
+
+// Pads the string in args with the given character, a space by default, up
+// to the given width in characters.
+func (in *Interpreter) pad(name string, args []obj.Object, left bool) obj.Object {
+	if err := checkArgc(name, args, 2, 3); err != nil {
+		return err
+	}
+	s, err := stringArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	width, err := intArg(name, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var fill = " "
+	if len(args) == 3 {
+		if fill, err = stringArg(name, args, 2); err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(fill) != 1 {
+			return newError("%s: padding must be a single character, got %q", name, fill)
+		}
+	}
+
+	n := width - int64(utf8.RuneCountInString(s))
+	if n <= 0 {
+		return args[0]
+	}
+	if in.tooLarge(n, len(fill)+len(s)) {
+		return limitError("size limit exceeded: %d", in.limits.MaxLen)
+	}
+	if tooLong(n, len(fill), len(s)) {
+		return newError("%s: result too long", name)
+	}
+	if left {
+		return stoo(strings.Repeat(fill, int(n)) + s)
+	}
+	return stoo(s + strings.Repeat(fill, int(n)))
+}
+
+// format(f, args...) formats args according to the printf-style format f.
+//
+// The supported verbs are %d, %x, %X, %o, %b and %c for integers, %e, %E,
+// %f, %g and %G for numbers, %s and %q for strings, %t for booleans and %v
+// for any value, with the same flags, width and precision as Go. Widths are
+// counted in characters.
+func (in *Interpreter) builtinFormat(args ...obj.Object) obj.Object {
+	if err := checkArgc("format", args, 1, -1); err != nil {
+		return err
+	}
+	f, err := stringArg("format", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var (
+		b    strings.Builder
+		argv = args[1:]
+		n    int
+	)
+
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			b.WriteByte(f[i])
+			continue
+		}
+
+		// Scan the flags, width and precision up to the verb.
+		j := i + 1
+		for j < len(f) && strings.IndexByte("+-# 0123456789.", f[j]) >= 0 {
+			j++
+		}
+		if j == len(f) {
+			return newError("format: missing verb at the end of %q", f)
+		}
+		verb, size := utf8.DecodeRuneInString(f[j:])
+		spec := f[i : j+size]
+		i = j + size - 1
+
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if n == len(argv) {
+			return newError("format: missing argument for %s", spec)
+		}
+
+		arg, err := in.formatArg(verb, argv[n])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, spec, arg)
+		n++
+	}
+
+	if n < len(argv) {
+		return newError("format: too many arguments: got %d, want %d", len(argv), n)
+	}
+	return stoo(b.String())
+}
+
+// Returns the Go value formatting o with verb produces the expected text.
+func (in *Interpreter) formatArg(verb rune, o obj.Object) (interface{}, obj.Object) {
+	switch verb {
+	case 'd', 'x', 'X', 'o', 'b', 'c':
+		switch i := o.(type) {
+		case *obj.Integer:
+			return i.Value, nil
+		case *obj.BigInt:
+			return i.Value, nil
+		}
+		return nil, newError("format: %%%c requires an integer, got %s", verb, o.Type())
+
+	case 'e', 'E', 'f', 'g', 'G':
+		if isNumber(o) {
+			return toFloat(o), nil
+		}
+		return nil, newError("format: %%%c requires a number, got %s", verb, o.Type())
+
+	case 's', 'q':
+		if s, ok := o.(*obj.String); ok {
+			return s.Value, nil
+		}
+		return nil, newError("format: %%%c requires a string, got %s", verb, o.Type())
+
+	case 't':
+		if b, ok := o.(*obj.Boolean); ok {
+			return b.Value, nil
+		}
+		return nil, newError("format: %%%c requires a boolean, got %s", verb, o.Type())
+
+	case 'v':
+		return in.inspect(o)
+
+	default:
+		return nil, newError("format: unknown verb %%%c", verb)
+	}
+}
@@ -0,0 +1,181 @@
+package evaluator
+
+import (
+	"math"
+	"time"
+	// The time zones must work on the hosts without a zone database too.
+	_ "time/tzdata"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Clock is the source of the current time of the time builtins.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// Clock of the host.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Returns t as milliseconds since the Unix epoch, which is how the time
+// builtins represent the times. The durations are milliseconds too.
+func millis(t time.Time) int64 {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
+
+// Returns the time ms milliseconds after the Unix epoch in UTC.
+func fromMillis(ms int64) time.Time {
+	return time.Unix(ms/1000, ms%1000*int64(time.Millisecond)).UTC()
+}
+
+// Converts the ms milliseconds given to the builtin name to a duration,
+// failing if they don't fit in one.
+func toDuration(name string, ms int64) (time.Duration, obj.Object) {
+	if ms > math.MaxInt64/int64(time.Millisecond) || ms < math.MinInt64/int64(time.Millisecond) {
+		return 0, newError("%s: duration out of range: %d", name, ms)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Returns the location named by the i-th argument of the builtin name, UTC
+// if it's missing.
+func locationArg(name string, args []obj.Object, i int) (*time.Location, obj.Object) {
+	if len(args) <= i {
+		return time.UTC, nil
+	}
+	zone, err := stringArg(name, args, i)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, e := time.LoadLocation(zone)
+	if e != nil {
+		return nil, newError("%s: unknown time zone: %s", name, zone)
+	}
+	return loc, nil
+}
+
+// now() returns the current time in milliseconds since the Unix epoch.
+func (in *Interpreter) builtinNow(args ...obj.Object) obj.Object {
+	if err := checkArgc("now", args, 0, 0); err != nil {
+		return err
+	}
+	return itoo(millis(in.Clock.Now()))
+}
+
+// sleep(ms) waits for ms milliseconds.
+func (in *Interpreter) builtinSleep(args ...obj.Object) obj.Object {
+	if err := checkArgc("sleep", args, 1, 1); err != nil {
+		return err
+	}
+	ms, err := intArg("sleep", args, 0)
+	if err != nil {
+		return err
+	}
+	if ms < 0 {
+		return newError("sleep: negative duration: %d", ms)
+	}
+	d, err := toDuration("sleep", ms)
+	if err != nil {
+		return err
+	}
+
+	// Sleeping past the deadline would only delay the timeout.
+	if in.limits.Timeout > 0 && time.Now().Add(d).After(in.usage.deadline) {
+		return limitError("timeout exceeded: %s", in.limits.Timeout)
+	}
+	in.Clock.Sleep(d)
+	return NULL
+}
+
+// format_time(t, layout, zone) formats the time t as layout in the time zone
+// zone, UTC by default. The layout is the one of Go's time package, such as
+// "2006-01-02 15:04:05".
+func builtinFormatTime(args ...obj.Object) obj.Object {
+	if err := checkArgc("format_time", args, 2, 3); err != nil {
+		return err
+	}
+	t, err := intArg("format_time", args, 0)
+	if err != nil {
+		return err
+	}
+	layout, err := stringArg("format_time", args, 1)
+	if err != nil {
+		return err
+	}
+	loc, err := locationArg("format_time", args, 2)
+	if err != nil {
+		return err
+	}
+	return stoo(fromMillis(t).In(loc).Format(layout))
+}
+
+// parse_time(s, layout, zone) returns the time s represents as formatted by
+// layout. If s has no time zone it's in zone, UTC by default.
+func builtinParseTime(args ...obj.Object) obj.Object {
+	if err := checkArgc("parse_time", args, 2, 3); err != nil {
+		return err
+	}
+	s, err := stringArg("parse_time", args, 0)
+	if err != nil {
+		return err
+	}
+	layout, err := stringArg("parse_time", args, 1)
+	if err != nil {
+		return err
+	}
+	loc, err := locationArg("parse_time", args, 2)
+	if err != nil {
+		return err
+	}
+
+	t, e := time.ParseInLocation(layout, s, loc)
+	if e != nil {
+		return newError("parse_time: %v", e)
+	}
+	return itoo(millis(t))
+}
+
+// duration(s) returns the milliseconds in the duration s, such as "1h30m" or
+// "2.5s". Durations are integers, so they add to and subtract from times.
+func builtinDuration(args ...obj.Object) obj.Object {
+	if err := checkArgc("duration", args, 1, 1); err != nil {
+		return err
+	}
+	s, err := stringArg("duration", args, 0)
+	if err != nil {
+		return err
+	}
+
+	d, e := time.ParseDuration(s)
+	if e != nil {
+		return newError("duration: %v", e)
+	}
+	return itoo(d.Milliseconds())
+}
+
+// format_duration(ms) returns the duration of ms milliseconds formatted like
+// "1h30m0s".
+func builtinFormatDuration(args ...obj.Object) obj.Object {
+	if err := checkArgc("format_duration", args, 1, 1); err != nil {
+		return err
+	}
+	ms, err := intArg("format_duration", args, 0)
+	if err != nil {
+		return err
+	}
+	d, err := toDuration("format_duration", ms)
+	if err != nil {
+		return err
+	}
+	return stoo(d.String())
+}
@@ -2,6 +2,11 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/NicoNex/monkey/ast"
 	"github.com/NicoNex/monkey/obj"
 )
@@ -10,8 +15,24 @@ var (
 	NULL  = &obj.Null{}
 	TRUE  = &obj.Boolean{Value: true}
 	FALSE = &obj.Boolean{Value: false}
+	EMPTY = &obj.String{Value: ""}
+)
+
+// Range of the integers that are preallocated and shared by all the
+// evaluations, integers are immutable so it's safe to reuse them.
+const (
+	minCachedInt = -128
+	maxCachedInt = 1024
 )
 
+var smallInts [maxCachedInt - minCachedInt + 1]obj.Integer
+
+func init() {
+	for i := range smallInts {
+		smallInts[i].Value = int64(i + minCachedInt)
+	}
+}
+
 // Returns the object representation of the boolean primitive b.
 func btoo(b bool) *obj.Boolean {
 	if b {
@@ -20,19 +41,33 @@ func btoo(b bool) *obj.Boolean {
 	return FALSE
 }
 
-func evalProgram(statements []ast.Statement, env *obj.Env) obj.Object {
-	var ret obj.Object
+// Returns the object representation of the integer primitive i.
+func itoo(i int64) *obj.Integer {
+	if i >= minCachedInt && i <= maxCachedInt {
+		return &smallInts[i-minCachedInt]
+	}
+	return &obj.Integer{Value: i}
+}
 
-	for _, s := range statements {
-		ret = Eval(s, env)
+// Returns the object representation of the string primitive s.
+func stoo(s string) *obj.String {
+	if s == "" {
+		return EMPTY
+	}
+	return &obj.String{Value: s}
+}
 
-		switch result := ret.(type) {
+func (in *Interpreter) evalProgram(statements []ast.Statement, env *obj.Env) obj.Object {
+	var ret obj.Object
 
-		case *obj.ReturnValue:
-			return result.Value
+	for _, s := range statements {
+		ret = in.Eval(s, env)
 
-		case *obj.Error:
-			return result
+		if rv, ok := ret.(*obj.ReturnValue); ok {
+			return rv.Value
+		}
+		if isError(ret) {
+			return ret
 		}
 	}
 	return ret
@@ -50,12 +85,22 @@ func evalBangOpExpr(right obj.Object) obj.Object {
 }
 
 func evalPrefixMinusOpExpr(right obj.Object) obj.Object {
-	if right.Type() != obj.INT {
+	switch r := right.(type) {
+	case *obj.Integer:
+		if r.Value == math.MinInt64 {
+			return bigtoo(new(big.Int).Neg(toBig(r)))
+		}
+		return itoo(-r.Value)
+
+	case *obj.BigInt:
+		return bigtoo(new(big.Int).Neg(r.Value))
+
+	case *obj.Float:
+		return ftoo(-r.Value)
+
+	default:
 		return newError("unknown operator: -%s", right.Type().String())
 	}
-
-	val := right.(*obj.Integer).Value
-	return &obj.Integer{Value: -val}
 }
 
 func evalPrefixExpr(op string, right obj.Object) obj.Object {
@@ -72,20 +117,30 @@ func evalPrefixExpr(op string, right obj.Object) obj.Object {
 	}
 }
 
-func evalInfixExpr(op string, left, right obj.Object) obj.Object {
+func (in *Interpreter) evalInfixExpr(op string, left, right obj.Object) obj.Object {
 	switch {
 
 	case left.Type() == obj.INT && right.Type() == obj.INT:
 		return evalIntInfixExpr(op, left, right)
 
+	case isInteger(left) && isInteger(right):
+		return evalBigInfixExpr(op, left, right)
+
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpr(op, left, right)
+
 	case left.Type() == obj.STRING && right.Type() == obj.STRING:
 		return evalStrInfixExpr(op, left, right)
 
-	case op == "==":
-		return btoo(left == right)
+	case left.Type() == obj.ARRAY && right.Type() == obj.ARRAY:
+		return in.evalArrayInfixExpr(op, left, right)
 
-	case op == "!=":
-		return btoo(left != right)
+	case op == "==", op == "!=":
+		eq, err := in.equal(left, right)
+		if err != nil {
+			return err
+		}
+		return btoo(eq == (op == "=="))
 
 	case left.Type() != right.Type():
 		lt := left.Type().String()
@@ -106,7 +161,7 @@ func evalStrInfixExpr(op string, left, right obj.Object) obj.Object {
 	switch op {
 
 	case "+":
-		return &obj.String{Value: l + r}
+		return stoo(l + r)
 
 	case "==":
 		return btoo(l == r)
@@ -114,6 +169,18 @@ func evalStrInfixExpr(op string, left, right obj.Object) obj.Object {
 	case "!=":
 		return btoo(l != r)
 
+	case "<":
+		return btoo(l < r)
+
+	case ">":
+		return btoo(l > r)
+
+	case "<=":
+		return btoo(l <= r)
+
+	case ">=":
+		return btoo(l >= r)
+
 	default:
 		lt := left.Type().String()
 		rt := right.Type().String()
@@ -121,23 +188,144 @@ func evalStrInfixExpr(op string, left, right obj.Object) obj.Object {
 	}
 }
 
+func (in *Interpreter) evalArrayInfixExpr(op string, left, right obj.Object) obj.Object {
+	switch op {
+
+	case "==", "!=":
+		eq, err := in.equal(left, right)
+		if err != nil {
+			return err
+		}
+		return btoo(eq == (op == "=="))
+
+	case "<", ">", "<=", ">=":
+		c, err := compareSeen(op, left, right, nil, in.charge)
+		if err != nil {
+			return err
+		}
+		return btoo(ordered(op, c))
+
+	default:
+		lt := left.Type().String()
+		rt := right.Type().String()
+		return newError("unknown operator: %s %s %s", lt, op, rt)
+	}
+}
+
+// Compares a and b returning -1, 0 or 1 if a is less than, equal to or
+// greater than b. Arrays are ordered lexicographically, op is the operator
+// the comparison is made for and it's only used in the error messages.
+func compare(op string, a, b obj.Object) (int, obj.Object) {
+	return compareSeen(op, a, b, nil, nil)
+}
+
+// Compares a and b like compare, calling charge, if not nil, for each pair
+// of array elements compared and failing with its error. The pairs of arrays
+// already compared are in seen, a pair found again is equal as in
+// obj.EqualFunc.
+func compareSeen(op string, a, b obj.Object, seen map[[2]*obj.Array]bool, charge func(int) obj.Object) (int, obj.Object) {
+	switch {
+	case a.Type() == obj.FLOAT || b.Type() == obj.FLOAT:
+		if isNumber(a) && isNumber(b) {
+			l, r := toFloat(a), toFloat(b)
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+		return 0, newError("type mismatch: %s %s %s", a.Type(), op, b.Type())
+
+	case a.Type() == obj.BIGINT || b.Type() == obj.BIGINT:
+		if isInteger(a) && isInteger(b) {
+			return toBig(a).Cmp(toBig(b)), nil
+		}
+		return 0, newError("type mismatch: %s %s %s", a.Type(), op, b.Type())
+
+	case a.Type() != b.Type():
+		return 0, newError("type mismatch: %s %s %s", a.Type(), op, b.Type())
+
+	case a.Type() == obj.INT:
+		l, r := a.(*obj.Integer).Value, b.(*obj.Integer).Value
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		}
+		return 0, nil
+
+	case a.Type() == obj.STRING:
+		return strings.Compare(a.(*obj.String).Value, b.(*obj.String).Value), nil
+
+	case a.Type() == obj.ARRAY:
+		var pair = [2]*obj.Array{a.(*obj.Array), b.(*obj.Array)}
+		if pair[0] == pair[1] || seen[pair] {
+			return 0, nil
+		}
+		if seen == nil {
+			seen = make(map[[2]*obj.Array]bool)
+		}
+		seen[pair] = true
+
+		l, r := pair[0].Elements, pair[1].Elements
+		for i := 0; i < len(l) && i < len(r); i++ {
+			if charge != nil {
+				if err := charge(1); err != nil {
+					return 0, err
+				}
+			}
+			if c, err := compareSeen(op, l[i], r[i], seen, charge); err != nil || c != 0 {
+				return c, err
+			}
+		}
+		return compare(op, itoo(int64(len(l))), itoo(int64(len(r))))
+
+	default:
+		return 0, newError("unknown operator: %s %s %s", a.Type(), op, b.Type())
+	}
+}
+
+// Reports whether the result c of a comparison satisfies op.
+func ordered(op string, c int) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case ">":
+		return c > 0
+	case "<=":
+		return c <= 0
+	default:
+		return c >= 0
+	}
+}
+
 func evalIntInfixExpr(op string, left, right obj.Object) obj.Object {
 	var l = left.(*obj.Integer).Value
 	var r = right.(*obj.Integer).Value
 
+	if overflows(op, l, r) {
+		return evalBigInfixExpr(op, left, right)
+	}
+
 	switch op {
 
 	case "+":
-		return &obj.Integer{Value: l + r}
+		return itoo(l + r)
 
 	case "-":
-		return &obj.Integer{Value: l - r}
+		return itoo(l - r)
 
 	case "*":
-		return &obj.Integer{Value: l * r}
+		return itoo(l * r)
 
 	case "/":
-		return &obj.Integer{Value: l / r}
+		if r == 0 {
+			return newError("division by zero")
+		}
+		return itoo(l / r)
 
 	case "==":
 		return btoo(l == r)
@@ -164,17 +352,17 @@ func evalIntInfixExpr(op string, left, right obj.Object) obj.Object {
 	}
 }
 
-func evalIfExpr(ie *ast.IfExpression, env *obj.Env) obj.Object {
-	var cond = Eval(ie.Condition, env)
+func (in *Interpreter) evalIfExpr(ie *ast.IfExpression, env *obj.Env) obj.Object {
+	var cond = in.Eval(ie.Condition, env)
 
 	if isError(cond) {
 		return cond
 	}
 
 	if isTruthy(cond) {
-		return Eval(ie.Consequence, env)
+		return in.Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return in.Eval(ie.Alternative, env)
 	}
 	return NULL
 }
@@ -183,27 +371,29 @@ func isTruthy(cond obj.Object) bool {
 	return cond != NULL && cond != FALSE
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *obj.Env) obj.Object {
+func (in *Interpreter) evalBlockStatement(block *ast.BlockStatement, env *obj.Env) obj.Object {
 	var res obj.Object
 
 	for _, s := range block.Statements {
-		res = Eval(s, env)
+		res = in.Eval(s, env)
 
-		if res != nil {
-			rt := res.Type()
-			if rt == obj.RETURN || rt == obj.ERROR {
-				return res
-			}
+		if res != nil && (res.Type() == obj.RETURN || isError(res)) {
+			return res
 		}
 	}
 	return res
 }
 
-func evalIdentifier(node *ast.Identifier, env *obj.Env) obj.Object {
+func (in *Interpreter) evalIdentifier(node *ast.Identifier, env *obj.Env) obj.Object {
+	for id := node; id != nil && id.Local; id = id.Outer {
+		if val := env.GetAt(id.Depth, id.Index); val != nil {
+			return val
+		}
+	}
 	if val, ok := env.Get(node.Value); ok {
 		return val
 	}
-	if val, ok := builtins[node.Value]; ok {
+	if val, ok := in.builtins[node.Value]; ok {
 		return val
 	}
 
@@ -214,27 +404,204 @@ func evalIndexExpression(left, index obj.Object) obj.Object {
 	switch {
 	case left.Type() == obj.ARRAY && index.Type() == obj.INT:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == obj.STRING && index.Type() == obj.INT:
+		return evalStringIndexExpression(left, index)
+	// Big indices are out of range of any array or string.
+	case (left.Type() == obj.ARRAY || left.Type() == obj.STRING) && index.Type() == obj.BIGINT:
+		return NULL
+	case left.Type() == obj.HASH:
+		return evalHashIndexExpression(left, index)
+	case left.Type() == obj.ERROR && index.Type() == obj.STRING:
+		return evalErrorIndexExpression(left, index)
+	case left.Type() == obj.MODULE && index.Type() == obj.STRING:
+		return evalModuleIndexExpression(left, index)
 	default:
 		return newError("index operator not supported %s", left.Type())
 	}
 }
 
+// Returns the element at the possibly negative index of array.
 func evalArrayIndexExpression(array, index obj.Object) obj.Object {
 	var arr = array.(*obj.Array)
-	var idx = index.(*obj.Integer).Value
-	var max = int64(len(arr.Elements) - 1)
+	var idx = absIndex(index.(*obj.Integer).Value, len(arr.Elements))
 
-	if idx < 0 || idx > max {
+	if idx < 0 || idx >= int64(len(arr.Elements)) {
 		return NULL
 	}
 	return arr.Elements[idx]
 }
 
-func evalExpressions(exps []ast.Expression, env *obj.Env) []obj.Object {
+// Returns the character at the possibly negative index of str as a string.
+// The string is walked up to the index only, from the end for the negative
+// ones.
+func evalStringIndexExpression(str, index obj.Object) obj.Object {
+	var s = str.(*obj.String).Value
+	var idx = index.(*obj.Integer).Value
+
+	if idx < 0 {
+		for ; s != ""; idx++ {
+			r, size := utf8.DecodeLastRuneInString(s)
+			if idx == -1 {
+				return stoo(string(r))
+			}
+			s = s[:len(s)-size]
+		}
+		return NULL
+	}
+
+	for ; s != ""; idx-- {
+		r, size := utf8.DecodeRuneInString(s)
+		if idx == 0 {
+			return stoo(string(r))
+		}
+		s = s[size:]
+	}
+	return NULL
+}
+
+// Evaluates left[start:end:step] where the bounds are nil if omitted.
+// Negative bounds count from the end and out of range ones return null.
+func evalSliceExpression(left, start, end, step obj.Object) obj.Object {
+	var l int
+
+	switch left := left.(type) {
+	case *obj.Array:
+		l = len(left.Elements)
+	case *obj.String:
+		l = utf8.RuneCountInString(left.Value)
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	var bounds = [3]int64{0, int64(l), 1}
+	for i, b := range []obj.Object{start, end, step} {
+		if b == nil {
+			continue
+		}
+		switch n := b.(type) {
+		case *obj.Integer:
+			bounds[i] = n.Value
+		// Big integers are past the ends of any sequence, like the integers
+		// closest to them.
+		case *obj.BigInt:
+			bounds[i] = math.MaxInt64
+			if n.Value.Sign() < 0 {
+				bounds[i] = math.MinInt64
+			}
+		default:
+			return newError("slice indices must be integers, got %s", b.Type())
+		}
+	}
+
+	var s, e, st = absIndex(bounds[0], l), absIndex(bounds[1], l), bounds[2]
+	if st <= 0 {
+		return newError("invalid slice step: %s", step.Inspect())
+	}
+	if s < 0 || e > int64(l) || s > e {
+		return NULL
+	}
+
+	// Computed so that neither the count nor the indices can overflow with
+	// huge steps.
+	var n int64
+	if e > s {
+		n = (e-s-1)/st + 1
+	}
+
+	switch left := left.(type) {
+	case *obj.String:
+		var runes = []rune(left.Value)
+		var ret = make([]rune, n)
+		for i := range ret {
+			ret[i] = runes[s+int64(i)*st]
+		}
+		return stoo(string(ret))
+
+	default:
+		var elements = left.(*obj.Array).Elements
+		var ret = make([]obj.Object, n)
+		for i := range ret {
+			ret[i] = elements[s+int64(i)*st]
+		}
+		return &obj.Array{Elements: ret}
+	}
+}
+
+// Evaluates the bounds of a slice expression, leaving the omitted ones nil.
+func (in *Interpreter) evalSliceBounds(node *ast.SliceExpression, env *obj.Env) ([]obj.Object, obj.Object) {
+	var bounds = make([]obj.Object, 3)
+
+	for i, b := range []ast.Expression{node.Start, node.End, node.Step} {
+		if b == nil {
+			continue
+		}
+		val := in.Eval(b, env)
+		if isError(val) {
+			return nil, val
+		}
+		bounds[i] = val
+	}
+	return bounds, nil
+}
+
+// Returns the field of the error named by index, or null.
+func evalErrorIndexExpression(err, index obj.Object) obj.Object {
+	var e = err.(*obj.Error)
+
+	switch index.(*obj.String).Value {
+	case "message":
+		return stoo(e.Msg)
+	case "kind":
+		return stoo(e.Kind)
+	case "position":
+		return itoo(int64(e.Pos))
+	default:
+		return NULL
+	}
+}
+
+func evalHashIndexExpression(hash, index obj.Object) obj.Object {
+	var h = hash.(*obj.Hash)
+
+	key, ok := index.(obj.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	if pair, ok := h.Pairs[key.HashKey()]; ok {
+		return pair.Value
+	}
+	return NULL
+}
+
+func (in *Interpreter) evalHashLiteral(node *ast.HashLiteral, env *obj.Env) obj.Object {
+	var pairs = make(map[obj.HashKey]obj.HashPair, len(node.Keys))
+
+	for _, k := range node.Keys {
+		key := in.Eval(k, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(obj.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		val := in.Eval(node.Pairs[k], env)
+		if isError(val) {
+			return val
+		}
+		pairs[hashKey.HashKey()] = obj.HashPair{Key: key, Value: val}
+	}
+	return &obj.Hash{Pairs: pairs}
+}
+
+func (in *Interpreter) evalExpressions(exps []ast.Expression, env *obj.Env) []obj.Object {
 	var ret []obj.Object
 
 	for _, e := range exps {
-		val := Eval(e, env)
+		val := in.Eval(e, env)
 		if isError(val) {
 			return []obj.Object{val}
 		}
@@ -243,12 +610,23 @@ func evalExpressions(exps []ast.Expression, env *obj.Env) []obj.Object {
 	return ret
 }
 
-func applyFunction(fn obj.Object, args []obj.Object) obj.Object {
+func (in *Interpreter) applyFunction(fn obj.Object, args []obj.Object) obj.Object {
 	switch fn := fn.(type) {
 
 	case *obj.Function:
-		extEnv := extendFuncEnv(fn, args)
-		result := Eval(fn.Body, extEnv)
+		if len(args) != len(fn.Params) {
+			return newError("wrong number of arguments: got %d, want %d", len(args), len(fn.Params))
+		}
+		if in.limits.MaxDepth > 0 && in.usage.depth >= in.limits.MaxDepth {
+			return limitError("call depth limit exceeded: %d", in.limits.MaxDepth)
+		}
+		extEnv, err := extendFuncEnv(fn, args)
+		if err != nil {
+			return err
+		}
+		in.usage.depth++
+		result := in.Eval(fn.Body, extEnv)
+		in.usage.depth--
 		return unwrapReturnValue(result)
 
 	case *obj.Builtin:
@@ -266,120 +644,213 @@ func unwrapReturnValue(o obj.Object) obj.Object {
 	return o
 }
 
-func extendFuncEnv(fn *obj.Function, args []obj.Object) *obj.Env {
-	var env = obj.NewEnclosedEnv(fn.Env)
+func extendFuncEnv(fn *obj.Function, args []obj.Object) (*obj.Env, obj.Object) {
+	var env = obj.NewEnclosedEnv(fn.Env, fn.Slots)
 
 	for i, p := range fn.Params {
-		env.Set(p.Value, args[i])
+		// The parameters have no slots if the resolver didn't run.
+		if !p.Local || p.Index >= fn.Slots {
+			return nil, newError("unresolved function: the program must go through resolver.Resolve")
+		}
+		env.SetAt(p.Index, args[i])
 	}
-	return env
+	return env, nil
 }
 
 func newError(format string, a ...interface{}) *obj.Error {
-	return &obj.Error{Msg: fmt.Sprintf(format, a...)}
+	return &obj.Error{Msg: fmt.Sprintf(format, a...), Kind: "runtime", Pos: -1}
 }
 
+// Reports whether o is an error being raised.
 func isError(o obj.Object) bool {
-	if o != nil {
-		return o.Type() == obj.ERROR
+	e, ok := o.(*obj.Error)
+	return ok && !e.Caught
+}
+
+// Sets the position of the error o raised at pos if it doesn't have one yet.
+func locate(o obj.Object, pos int) obj.Object {
+	if e, ok := o.(*obj.Error); ok && !e.Caught && e.Pos < 0 {
+		e.Pos = pos
+	}
+	return o
+}
+
+func evalThrowStatement(val obj.Object, pos int) obj.Object {
+	switch val := val.(type) {
+	case *obj.Error:
+		err := *val
+		err.Caught = false
+		if err.Pos < 0 {
+			err.Pos = pos
+		}
+		return &err
+
+	case *obj.String:
+		return &obj.Error{Msg: val.Value, Kind: "error", Pos: pos}
+
+	default:
+		return locate(newError("cannot throw %s", val.Type()), pos)
 	}
-	return false
 }
 
-func Eval(node ast.Node, env *obj.Env) obj.Object {
+func (in *Interpreter) evalTryExpression(node *ast.TryExpression, env *obj.Env) obj.Object {
+	var res = in.Eval(node.Body, env)
+
+	if !isError(res) || res.(*obj.Error).Fatal {
+		return res
+	}
+
+	err := *res.(*obj.Error)
+	err.Caught = true
+	if node.Param.Local {
+		env.SetAt(node.Param.Index, &err)
+	} else {
+		env.Set(node.Param.Value, &err)
+	}
+	return in.Eval(node.Catch, env)
+}
+
+// Eval evaluates node in the environment env. The node must have been
+// through resolver.Resolve, as parser.Parse does.
+func (in *Interpreter) Eval(node ast.Node, env *obj.Env) obj.Object {
+	if in.limited {
+		if err := in.step(); err != nil {
+			return err
+		}
+	}
+
 	switch node := node.(type) {
 
 	// Statements
 	case *ast.Program:
-		return evalProgram(node.Statements, env)
+		return in.evalProgram(node.Statements, env)
 
 	case *ast.ExpressionStatement:
-		return Eval(node.Expr, env)
+		return in.Eval(node.Expr, env)
 
 	// Expressions
 	case *ast.IntegerLiteral:
-		return &obj.Integer{Value: node.Value}
+		if node.Big != nil {
+			return &obj.BigInt{Value: node.Big}
+		}
+		return itoo(node.Value)
+
+	case *ast.FloatLiteral:
+		return ftoo(node.Value)
 
 	case *ast.Boolean:
 		return btoo(node.Value)
 
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := in.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpr(node.Operator, right)
+		return locate(evalPrefixExpr(node.Operator, right), node.Token.Pos)
 
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
+		left := in.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		right := Eval(node.Right, env)
+		right := in.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpr(node.Operator, left, right)
+		return in.bounded(locate(in.evalInfixExpr(node.Operator, left, right), node.Token.Pos))
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return in.evalBlockStatement(node, env)
 
 	case *ast.IfExpression:
-		return evalIfExpr(node, env)
+		return in.evalIfExpr(node, env)
 
 	case *ast.ReturnStatement:
-		val := Eval(node.Value, env)
+		val := in.Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
 		return &obj.ReturnValue{Value: val}
 
+	case *ast.ThrowStatement:
+		val := in.Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return evalThrowStatement(val, node.Token.Pos)
+
+	case *ast.TryExpression:
+		return in.evalTryExpression(node, env)
+
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
+		val := in.Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Name.Local {
+			env.SetAt(node.Name.Index, val)
+		} else {
+			env.Set(node.Name.Value, val)
+		}
+
+	case *ast.ImportStatement:
+		return in.evalImportStatement(node, env)
 
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return locate(in.evalIdentifier(node, env), node.Token.Pos)
 
 	case *ast.FunctionLiteral:
 		params := node.Params
 		body := node.Body
-		return &obj.Function{Params: params, Env: env, Body: body}
+		return &obj.Function{Params: params, Env: env, Body: body, Slots: node.Slots}
 
 	case *ast.CallExpression:
-		fn := Eval(node.Func, env)
+		fn := in.Eval(node.Func, env)
 		if isError(fn) {
 			return fn
 		}
-		args := evalExpressions(node.Args, env)
+		args := in.evalExpressions(node.Args, env)
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(fn, args)
+		return in.bounded(locate(in.applyFunction(fn, args), node.Token.Pos))
 
 	case *ast.StringLiteral:
-		return &obj.String{Value: node.Value}
+		return stoo(node.Value)
 
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
+		elements := in.evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
+		// Unlike the empty string, the empty array can't be shared since
+		// push changes its array in place.
 		return &obj.Array{Elements: elements}
 
+	case *ast.HashLiteral:
+		return locate(in.evalHashLiteral(node, env), node.Token.Pos)
+
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
+		left := in.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		index := Eval(node.Index, env)
+		index := in.Eval(node.Index, env)
 		if isError(index) {
 			return index
 		}
-		return evalIndexExpression(left, index)
+		return locate(evalIndexExpression(left, index), node.Token.Pos)
+
+	case *ast.SliceExpression:
+		left := in.Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		bounds, err := in.evalSliceBounds(node, env)
+		if err != nil {
+			return err
+		}
+		return locate(evalSliceExpression(left, bounds[0], bounds[1], bounds[2]), node.Token.Pos)
 	}
 
 	return nil
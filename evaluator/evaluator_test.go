@@ -1,10 +1,22 @@
 package evaluator
 
 import (
+	"bytes"
+	"errors"
+	"github.com/NicoNex/monkey/ast"
 	"github.com/NicoNex/monkey/lexer"
 	"github.com/NicoNex/monkey/obj"
 	"github.com/NicoNex/monkey/parser"
+	"io/fs"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func testEval(input string) obj.Object {
@@ -59,6 +71,229 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"9223372036854775807 * 9223372036854775807", "85070591730234615847396907784232501249"},
+		{"let m = -9223372036854775807 - 1; -m", "9223372036854775808"},
+		{"let m = -9223372036854775807 - 1; m / -1", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"-123456789012345678901234567890", "-123456789012345678901234567890"},
+		{"123456789012345678901234567890 / 10", "12345678901234567890123456789"},
+		{"-99999999999999999999 / 7", "-14285714285714285714"},
+		{"let f = fn(n) { if (n == 0) { 1 } else { n * f(n - 1) } }; f(25)", "15511210043330985984000000"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		big, ok := evaluated.(*obj.BigInt)
+		if !ok {
+			t.Errorf("%s: object is not BigInt. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if big.Inspect() != tt.expected {
+			t.Errorf("%s: wrong value. expected=%s, got=%s", tt.input, tt.expected, big.Inspect())
+		}
+	}
+
+	// Results that fit again are plain integers.
+	mixed := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775807 + 1 - 1", 9223372036854775807},
+		{"99999999999999999999 - 99999999999999999990", 9},
+		{"(9223372036854775807 + 1) * 0", 0},
+		{"99999999999999999999 > 1", true},
+		{"-99999999999999999999 < -9223372036854775807", true},
+		{"99999999999999999999 == 99999999999999999999", true},
+		{"99999999999999999999 == 99999999999999999998 + 1", true},
+		{"9223372036854775807 + 1 == 9223372036854775808", true},
+		{"9223372036854775807 != 9223372036854775808", true},
+		{"{99999999999999999999: 1}[99999999999999999998 + 1]", 1},
+		{"[99999999999999999999] < [100000000000000000000]", true},
+		{"[1] < [99999999999999999999]", true},
+		{"99999999999999999999 / 0", "division by zero"},
+		{"99999999999999999999 + true", "type mismatch: INTEGER + BOOLEAN"},
+		{"slice([1], 99999999999999999999)", "slice: second argument out of range: 99999999999999999999"},
+		{`format("%d %x", 99999999999999999999, 18446744073709551615)`, "99999999999999999999 ffffffffffffffff"},
+	}
+
+	for _, tt := range mixed {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if s, ok := evaluated.(*obj.String); ok {
+				testStringObject(t, s, expected)
+				continue
+			}
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func testFloatObject(t *testing.T, o obj.Object, expected float64) bool {
+	result, ok := o.(*obj.Float)
+	if !ok {
+		t.Errorf("object is not Float, got %T (%+v)", o, o)
+		return false
+	}
+	if math.Abs(result.Value-expected) > 1e-12 {
+		t.Errorf("object has wrong value, got %g, want %g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestFloats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1.5", 1.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1", 2.5},
+		{"1 + 1.5", 2.5},
+		{"0.1 + 0.2", 0.30000000000000004},
+		{"3 / 2.0", 1.5},
+		{"2.0 * 3", 6.0},
+		{"1e3 - 1", 999.0},
+		{"92233720368547758070 * 0.1", 9223372036854775807.0},
+		{"1.5 < 2", true},
+		{"2 >= 2.0", true},
+		{"1 == 1.0", true},
+		{"1.5 != 1.5", false},
+		{"[1.5, 1] < [1.5, 2]", true},
+		{"1.0 / 0", "division by zero"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+		{"\"a\" * 1.5", "type mismatch: STRING * FLOAT"},
+		{"{1.5: 1}", "unusable as hash key: FLOAT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+
+	inspect := map[string]string{
+		"1.5":     "1.5",
+		"2.0":     "2.0",
+		"1e21":    "1e+21",
+		"-0.25":   "-0.25",
+		"1.0 / 3": "0.3333333333333333",
+	}
+	for input, expected := range inspect {
+		if actual := testEval(input).Inspect(); actual != expected {
+			t.Errorf("%s: wrong inspect. expected=%s, got=%s", input, expected, actual)
+		}
+	}
+}
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"abs(-5)", 5},
+		{"abs(5)", 5},
+		{"abs(-2.5)", 2.5},
+		{"abs(-9223372036854775807 - 1) == 9223372036854775808", true},
+		{"abs(\"a\")", "abs: first argument must be a number, got STRING"},
+		{"min(3, 1, 2)", 1},
+		{"max(3, 1, 2)", 3},
+		{"min([4, 2.5, 3])", 2.5},
+		{"max([1])", 1},
+		{"max(1, 99999999999999999999) == 99999999999999999999", true},
+		{"min([])", "min: empty array"},
+		{"min()", "min: wrong number of arguments: got 0, want at least 1"},
+		{"max(1, \"a\")", "max: arguments must be numbers, got STRING"},
+		{"pow(2, 10)", 1024},
+		{"pow(2, 64) == 18446744073709551616", true},
+		{"pow(-3, 3)", -27},
+		{"pow(2, -1)", 0.5},
+		{"pow(2.0, 3)", 8.0},
+		{"pow(4, 0.5)", 2.0},
+		{"pow(0, -1)", "division by zero"},
+		{"pow(-8, 0.5)", "pow: argument out of domain: -8"},
+		{"pow(2, 99999999999999999999)", "pow: second argument out of range: 99999999999999999999"},
+		{"sqrt(16)", 4.0},
+		{"sqrt(2.25)", 1.5},
+		{"sqrt(-1)", "sqrt: argument out of domain: -1"},
+		{"sqrt(-0.5)", "sqrt: argument out of domain: -0.5"},
+		{"floor(2.7)", 2},
+		{"floor(-2.5)", -3},
+		{"ceil(2.1)", 3},
+		{"round(2.5)", 3},
+		{"round(-2.5)", -3},
+		{"round(7)", 7},
+		{"round(1e20) == 100000000000000000000", true},
+		{"floor(1e308 * 10)", "floor: cannot convert +Inf to an integer"},
+		{"format(\"%.2f|%g|%e\", 3.14159, 0.5, 1500)", "3.14|0.5|1.500000e+03"},
+		{"format(\"%f\", \"a\")", "format: %f requires a number, got STRING"},
+		{"clamp(5, 1, 3)", 3},
+		{"clamp(-5, 1, 3)", 1},
+		{"clamp(2.5, 1, 3)", 2.5},
+		{"clamp(1, 3, 1)", "clamp: lower bound 3 greater than upper bound 1"},
+		{"gcd(12, 18)", 6},
+		{"gcd(-12, 18)", 6},
+		{"gcd(0, 0)", 0},
+		{"gcd(1.5, 3)", "gcd: first argument must be an integer, got FLOAT"},
+		{"sum([1, 2, 3])", 6},
+		{"sum([])", 0},
+		{"sum([1, 0.5])", 1.5},
+		{"sum([9223372036854775807, 1]) == 9223372036854775808", true},
+		{"sum([1, \"a\"])", "sum: elements must be numbers, got STRING"},
+		{"sin(0)", 0.0},
+		{"cos(0)", 1.0},
+		{"tan(0)", 0.0},
+		{"asin(1)", math.Pi / 2},
+		{"acos(1)", 0.0},
+		{"acos(2)", "acos: argument out of domain: 2"},
+		{"atan(1)", math.Pi / 4},
+		{"atan2(1, 1)", math.Pi / 4},
+		{"float(3)", 3.0},
+		{"int(2.9)", 2},
+		{"int(-2.9)", -2},
+		{"int(7)", 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -93,6 +328,171 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestStructuralComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2] == [1, 2]`, true},
+		{`[1, 2] != [1, 2]`, false},
+		{`[1, 2] == [2, 1]`, false},
+		{`[1, 2] == [1, 2, 3]`, false},
+		{`[] == []`, true},
+		{`[1, "a", [true]] == [1, "a", [true]]`, true},
+		{`[1] == ["1"]`, false},
+		{`[1] == 1`, false},
+		{`let a = [1]; let b = a; a == b`, true},
+		{`let a = [1]; let b = push([], 1); a == b`, true},
+		{`{"a": [1], 2: true} == {2: true, "a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{} == []`, false},
+		{`"a" + "b" == "ab"`, true},
+		{`let f = fn() { 1 }; f == f`, true},
+		{`fn() { 1 } == fn() { 1 }`, false},
+		{`len == len`, true},
+		{`contains([[1, 2]], [1, 2])`, true},
+		{`index_of([{"a": 1}], {"a": 1})`, 0},
+		{`[1] == [1.0]`, true},
+		{`[1] != [1.0]`, false},
+		{`[1] == [1.5]`, false},
+		{`[[2.0], 3] == [[2], 3.0]`, true},
+		{`{"a": 1} == {"a": 1.0}`, true},
+		{`[9223372036854775808] == [9223372036854775808.0]`, true},
+		{`contains([1.0], 1)`, true},
+		{`contains([1], 1.0)`, true},
+		{`index_of([2, 1.0], 1)`, 1},
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"ab" > "a"`, true},
+		{`"a" <= "a"`, true},
+		{`"a" >= "b"`, false},
+		{`"Z" < "a"`, true},
+		{`[1, 2] < [1, 3]`, true},
+		{`[1, 2] < [1, 2]`, false},
+		{`[1, 2] <= [1, 2]`, true},
+		{`[1, 2] < [1, 2, 0]`, true},
+		{`[2] > [1, 9]`, true},
+		{`[] < [1]`, true},
+		{`[["a"], 1] < [["b"], 0]`, true},
+		{`["a"] >= ["a"]`, true},
+		{`[1] < ["a"]`, "type mismatch: INTEGER < STRING"},
+		{`[true] < [false]`, "unknown operator: BOOLEAN < BOOLEAN"},
+		{`[1] + [2]`, "unknown operator: ARRAY + ARRAY"},
+		{`"a" < 1`, "type mismatch: STRING < INTEGER"},
+		{`let a = [1]; push(a, a); a == a`, true},
+		{`let a = [1]; push(a, a); let b = [1]; push(b, b); a == b`, true},
+		{`let a = [1]; push(a, a); let b = [2]; push(b, b); a == b`, false},
+		{`let a = [1]; push(a, a); let b = [1]; push(b, b); contains([b], a)`, true},
+		{`let a = [1]; push(a, a); let b = [1]; push(b, b); index_of([1, b], a)`, 1},
+		{`let a = [1]; push(a, a); let b = [1]; push(b, b); a < b`, false},
+		{`let a = [1]; push(a, a); let b = [1]; push(b, b); a <= b`, true},
+		{`let a = [1]; push(a, a); let b = [2]; push(b, b); a < b`, true},
+		{`let a = [1]; push(a, a); a < a`, false},
+		{`let a = [1]; let h = {"a": a}; push(a, h); let b = [1]; push(b, {"a": b}); a == b`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestTryCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { 1 / 0 } catch (e) { 2 }`, 2},
+		{`try { 1 / 0 } catch (e) { e["message"] }`, "division by zero"},
+		{`try { 1 / 0 } catch (e) { e["kind"] }`, "runtime"},
+		{`try { 1 / 0 } catch (e) { e["position"] }`, 8},
+		{`try { throw "boom"; 1 } catch (e) { e["message"] }`, "boom"},
+		{`try { throw "boom" } catch (e) { e["kind"] }`, "error"},
+		{`try { throw "boom" } catch (e) { e["position"] }`, 6},
+		{`try { throw error("bad input", "value") } catch (e) { e["kind"] }`, "value"},
+		{`try { throw error("bad input") } catch (e) { e["kind"] }`, "error"},
+		{`try { throw error("x") } catch (e) { e["nope"] }`, nil},
+		{`try { x } catch (e) { e["message"] }`, "identifier not found: x"},
+		// Errors unwind the calls up to the nearest try.
+		{`let f = fn() { let g = fn() { throw "deep"; }; g(); 1 }; try { f() } catch (e) { e["message"] }`, "deep"},
+		{`let f = fn() { try { throw "in" } catch (e) { return 1; }; 2 }; f()`, 1},
+		{`let f = fn(n) { if (n < 0) { throw "negative" }; n }; try { f(1) + f(-1) } catch (e) { e["message"] }`, "negative"},
+		{`try { map([1, 0], fn(x) { 1 / x }) } catch (e) { e["message"] }`, "division by zero"},
+		// Catch blocks can throw again, keeping the original error.
+		{`try { try { throw "a" } catch (e) { throw e } } catch (e) { e["message"] + "!" }`, "a!"},
+		{`try { try { 1 / 0 } catch (e) { throw e } } catch (e) { e["position"] }`, 14},
+		{`try { throw "a" } catch (e) { throw "b" }`, "b"},
+		{`try { throw 1 } catch (e) { e["message"] }`, "cannot throw INTEGER"},
+		// The caught error is bound in the enclosing scope.
+		{`let f = fn() { try { throw "x" } catch (err) { 0 }; err["message"] }; f()`, "x"},
+		{`try { throw "x" } catch (e) { 0 }; is_error(e)`, true},
+		// Error values don't propagate until they're thrown.
+		{`let e = error("x"); 1`, 1},
+		{`let f = fn() { error("x") }; is_error(f())`, true},
+		{`is_error(1)`, false},
+		{`is_error(try { 1 / 0 } catch (e) { e })`, true},
+		{`error("x") == error("x")`, true},
+		{`error("x") == error("x", "y")`, false},
+		{`error(1)`, "error: first argument must be a string, got INTEGER"},
+		{`throw "uncaught"; 1`, "uncaught"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"1 + true", 2},
+		{"let f = fn(x) { x / 0 }; f(1)", 18},
+		{"len(1)", 3},
+		{"-true", 0},
+		{"[1][\"a\"]", 3},
+		{"undefined", 0},
+		{`throw "x"`, 0},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(tt.input).(*obj.Error)
+		if !ok {
+			t.Errorf("%s: object is not Error", tt.input)
+			continue
+		}
+		if err.Pos != tt.pos {
+			t.Errorf("%s: wrong position. expected=%d, got=%d", tt.input, tt.pos, err.Pos)
+		}
+	}
+}
+
 func TestBangOperator(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -243,6 +643,10 @@ func TestErrorHandling(t *testing.T) {
 			`"Hello" - "World"`,
 			"invalid operator: STRING - STRING",
 		},
+		{
+			"10 / (5 - 5)",
+			"division by zero",
+		},
 	}
 
 	for _, tt := range tests {
@@ -320,6 +724,21 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestTypeAnnotationsAreIgnored(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x: int = 5; x;", 5},
+		{"let add = fn(a: int, b: int) -> int { a + b; }; add(2, 3);", 5},
+		{"let f: fn([int]) -> int = fn(a: [int]) { len(a) }; f([1, 2]);", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestEnclosingEnvironments(t *testing.T) {
 	input := `
 let first = 10;
@@ -337,6 +756,70 @@ ourFunction(20) + first + second;`
 	testIntegerObject(t, testEval(input), 70)
 }
 
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let newAdder = fn(x) { fn(y) { x + y } }; let addTwo = newAdder(2); addTwo(3);", 5},
+		{"let f = fn(a) { fn(b) { fn(c) { a * 100 + b * 10 + c } } }; f(1)(2)(3);", 123},
+		// Each call gets its own slots.
+		{"let mk = fn(x) { fn() { x } }; let a = mk(1); let b = mk(2); a() * 10 + b();", 12},
+		// Functions can refer to later bindings of the enclosing scope.
+		{"let f = fn() { g() }; let g = fn() { 7 }; f();", 7},
+		{"let f = fn() { let a = fn() { b() }; let b = fn() { 8 }; a() }; f();", 8},
+		// A local is visible only once it's been declared.
+		{"let x = 1; let f = fn() { let y = x; let x = 2; y * 10 + x }; f();", 12},
+		{"let x = 1; let f = fn(x) { let x = x + 1; x }; f(5) + x;", 7},
+		{"let f = fn() { if (false) { let a = 1 }; a }; f();", "identifier not found: a"},
+		{"let fib = fn(n) { if (n < 2) { return n }; fib(n - 1) + fib(n - 2) }; fib(15);", 610},
+		{"let f = fn(a, b) { a }; f(1);", "wrong number of arguments: got 1, want 2"},
+		// Until a local is set, closures see the binding further out.
+		{"let x = 1; let f = fn() { let g = fn() { x }; let r = g(); let x = 2; r }; f();", 1},
+		{"let x = 1; let f = fn() { let g = fn() { x }; let x = 2; g() }; f();", 2},
+		{"let f = fn(x) { let g = fn() { fn() { x } }; let h = g(); let x = 3; h() }; f(2);", 3},
+		{"let f = fn(x) { let g = fn() { let h = fn() { x }; let r = h(); let x = 4; r }; g() }; f(2);", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*obj.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Msg != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Msg)
+			}
+		}
+	}
+}
+
+func TestUnresolvedFunction(t *testing.T) {
+	// fn(x) { x }(1) built by hand, so the resolver never saw it.
+	x := &ast.Identifier{Value: "x"}
+	prog := &ast.Program{Statements: []ast.Statement{
+		&ast.ExpressionStatement{Expr: &ast.CallExpression{
+			Func: &ast.FunctionLiteral{
+				Params: []*ast.Identifier{x},
+				Body:   &ast.BlockStatement{Statements: []ast.Statement{&ast.ExpressionStatement{Expr: x}}},
+			},
+			Args: []ast.Expression{&ast.IntegerLiteral{Value: 1}},
+		}},
+	}}
+
+	in := New()
+	testErrorObject(t, in.Eval(prog, obj.NewEnv()), "unresolved function: the program must go through resolver.Resolve")
+	if in.usage.depth != 0 {
+		t.Errorf("call depth not restored, got %d", in.usage.depth)
+	}
+}
+
 func testBooleanObject(t *testing.T, o obj.Object, expected bool) bool {
 	result, ok := o.(*obj.Boolean)
 	if !ok {
@@ -430,6 +913,18 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
+			nil,
+		},
+		{
+			"[1, 2][99999999999999999999]",
 			nil,
 		},
 	}
@@ -445,6 +940,128 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func testStringObject(t *testing.T, o obj.Object, expected string) bool {
+	str, ok := o.(*obj.String)
+	if !ok {
+		t.Errorf("object is not String. got=%T (%+v)", o, o)
+		return false
+	}
+	if str.Value != expected {
+		t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		return false
+	}
+	return true
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[4]`, "o"},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[-5]`, "h"},
+		{`"héllo"[1]`, "é"},
+		{`"日本語"[-1]`, "語"},
+		{`let s = "abc"; let i = 1; s[i + 1]`, "c"},
+		{`"hello"[5]`, nil},
+		{`"hello"[-6]`, nil},
+		{`"hello"[-99999999999999999999]`, nil},
+		{`""[0]`, nil},
+		{`"hello"["a"]`, "index operator not supported STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if err, ok := evaluated.(*obj.Error); ok {
+				if err.Msg != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Msg)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2, 3, 4][1:3]`, []int{2, 3}},
+		{`[1, 2, 3, 4][:2]`, []int{1, 2}},
+		{`[1, 2, 3, 4][2:]`, []int{3, 4}},
+		{`[1, 2, 3, 4][:]`, []int{1, 2, 3, 4}},
+		{`[1, 2, 3, 4][-2:]`, []int{3, 4}},
+		{`[1, 2, 3, 4][:-1]`, []int{1, 2, 3}},
+		{`[1, 2, 3, 4, 5][::2]`, []int{1, 3, 5}},
+		{`[1, 2, 3, 4, 5][1::2]`, []int{2, 4}},
+		{`[1, 2, 3, 4, 5][1:4:2]`, []int{2, 4}},
+		{`[1, 2, 3][3:]`, []int{}},
+		{`[1, 2, 3][2:1]`, nil},
+		{`[1, 2, 3][4:]`, nil},
+		{`[1, 2, 3][:4]`, nil},
+		{`[1, 2, 3][-4:]`, nil},
+		{`[1, 2, 3][::9223372036854775807]`, []int{1}},
+		{`[1, 2, 3][2::9223372036854775807]`, []int{3}},
+		{`[1, 2, 3][1:1:9223372036854775807]`, []int{}},
+		{`"hello"[1:3]`, "el"},
+		{`"héllo"[1:3]`, "él"},
+		{`"日本語です"[-2:]`, "です"},
+		{`"hello"[::2]`, "hlo"},
+		{`"hello"[:]`, "hello"},
+		{`"hello"[5:]`, ""},
+		{`"hello"[6:]`, nil},
+		{`"abc"[1::9223372036854775807]`, "b"},
+		{`"héllo"[-1]`, "o"},
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[-4]`, "é"},
+		{`"héllo"[-5]`, "h"},
+		{`"héllo"[5]`, nil},
+		{`"héllo"[-6]`, nil},
+		{`""[0]`, nil},
+		{`""[-1]`, nil},
+		{`[1, 2, 3][99999999999999999999:]`, nil},
+		{`[1, 2, 3][:99999999999999999999]`, nil},
+		{`[1, 2, 3][-99999999999999999999:]`, nil},
+		{`[1, 2, 3][::99999999999999999999]`, []int{1}},
+		{`"abc"[1::99999999999999999999]`, "b"},
+		{`[1, 2][::-99999999999999999999]`, "invalid slice step: -99999999999999999999"},
+		{`[1, 2][::0]`, "invalid slice step: 0"},
+		{`[1, 2][::-1]`, "invalid slice step: -1"},
+		{`[1, 2]["a":]`, "slice indices must be integers, got STRING"},
+		{`5[1:]`, "slice operator not supported: INTEGER"},
+		{`[1, 2][1:true + 1]`, "type mismatch: BOOLEAN + INTEGER"},
+		{`let a = [1, 2, 3]; let b = a[:]; push(b, 4); a`, []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case []int:
+			testIntegerArray(t, evaluated, expected)
+		case string:
+			if err, ok := evaluated.(*obj.Error); ok {
+				if err.Msg != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Msg)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -454,20 +1071,61 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
 		{`len(1)`, "len: type not supported, got INTEGER"},
-		{`len("one", "two")`, "wrong number of arguments: got 2, want 1"},
-		// {`len([1, 2, 3])`, 3},
-		// {`len([])`, 0},
+		{`len("one", "two")`, "len: wrong number of arguments: got 2, want 1"},
+		{`len([1, 2, 3])`, 3},
+		{`len([])`, 0},
+		{`append([], 1)`, []int{1}},
+		{`append([1], 2, 3)`, []int{1, 2, 3}},
+		{`append([1])`, []int{1}},
+		{`append(1, 1)`, "append: first argument must be an array"},
+		{`append()`, "append: no arguments provided"},
 		// {`puts("hello", "world!")`, nil},
-		// {`first([1, 2, 3])`, 1},
-		// {`first([])`, nil},
-		// {`first(1)`, "argument to `first` must be ARRAY, got INTEGER"},
-		// {`last([1, 2, 3])`, 3},
-		// {`last([])`, nil},
-		// {`last(1)`, "argument to `last` must be ARRAY, got INTEGER"},
-		// {`rest([1, 2, 3])`, []int{2, 3}},
-		// {`rest([])`, nil},
-		// {`push([], 1)`, []int{1}},
-		// {`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, nil},
+		{`first(1)`, "first: first argument must be an array, got INTEGER"},
+		{`first([1], [2])`, "first: wrong number of arguments: got 2, want 1"},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, nil},
+		{`last(1)`, "last: first argument must be an array, got INTEGER"},
+		{`rest([1, 2, 3])`, []int{2, 3}},
+		{`rest([1])`, []int{}},
+		{`rest([])`, nil},
+		{`slice([1, 2, 3, 4], 1, 3)`, []int{2, 3}},
+		{`slice([1, 2, 3, 4], 2)`, []int{3, 4}},
+		{`slice([1, 2, 3, 4], -2)`, []int{3, 4}},
+		{`slice([1, 2, 3, 4], 0, -1)`, []int{1, 2, 3}},
+		{`slice([1, 2, 3, 4], 4)`, []int{}},
+		{`slice([1, 2, 3, 4], 5)`, nil},
+		{`slice([1, 2, 3, 4], 3, 1)`, nil},
+		{`slice([1, 2, 3, 4], 0, 5)`, nil},
+		{`slice([1], "a")`, "slice: second argument must be an integer, got STRING"},
+		{`slice([1])`, "slice: wrong number of arguments: got 1, want 2 to 3"},
+		{`reverse([1, 2, 3])`, []int{3, 2, 1}},
+		{`reverse([])`, []int{}},
+		{`reverse("abc")`, "reverse: first argument must be an array, got STRING"},
+		{`contains([1, 2, 3], 2)`, true},
+		{`contains([1, 2, 3], 4)`, false},
+		{`contains(["a", "b"], "b")`, true},
+		{`contains([true], true)`, true},
+		{`contains([1], "1")`, false},
+		{`index_of([1, 2, 3], 3)`, 2},
+		{`index_of(["a", "b"], "c")`, -1},
+		{`index_of(1, 1)`, "index_of: first argument must be an array, got INTEGER"},
+		{`concat([1], [2, 3], [])`, []int{1, 2, 3}},
+		{`concat()`, []int{}},
+		{`concat([1], 2)`, "concat: argument 2 must be an array, got INTEGER"},
+		{`flatten([1, [2, [3, [4]]]])`, []int{1, 2, 3, 4}},
+		{`len(flatten([1, [2, [3, [4]]]], 1))`, 3},
+		{`flatten([1, [2, [3, [4]]]], 2)[3][0]`, 4},
+		{`flatten([[], [1]])`, []int{1}},
+		{`flatten(1)`, "flatten: first argument must be an array, got INTEGER"},
+		{`let a = [1]; push(a, a); flatten(a)`, "flatten: array contains itself"},
+		{`let a = [1]; push(a, [a]); flatten(a)`, "flatten: array contains itself"},
+		{`let a = [1]; push(a, a); len(flatten(a, 3))`, 5},
+		{`let a = [1, 2]; flatten([a, a])`, []int{1, 2, 1, 2}},
+		{`push([], 1)`, []int{1}},
+		{`push([1], 2, 3)`, []int{1, 2, 3}},
+		{`push(1, 1)`, "push: first argument must be an array"},
 	}
 
 	for _, tt := range tests {
@@ -478,6 +1136,8 @@ func TestBuiltinFunctions(t *testing.T) {
 			testIntegerObject(t, evaluated, int64(expected))
 		case nil:
 			testNullObject(t, evaluated)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
 		case string:
 			errObj, ok := evaluated.(*obj.Error)
 			if !ok {
@@ -489,22 +1149,1204 @@ func TestBuiltinFunctions(t *testing.T) {
 				t.Errorf("wrong error message. expected=%q, got=%q",
 					expected, errObj.Msg)
 			}
-			// case []int:
-			// 	array, ok := evaluated.(*obj.Array)
-			// 	if !ok {
-			// 		t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
-			// 		continue
-			// 	}
-
-			// 	if len(array.Elements) != len(expected) {
-			// 		t.Errorf("wrong num of elements. want=%d, got=%d",
-			// 			len(expected), len(array.Elements))
-			// 		continue
-			// 	}
-
-			// 	for i, expectedElem := range expected {
-			// 		testIntegerObject(t, array.Elements[i], int64(expectedElem))
-			// 	}
+		case []int:
+			testIntegerArray(t, evaluated, expected)
+		}
+	}
+}
+
+func TestCachedObjects(t *testing.T) {
+	if a, b := testEval("5"), testEval("2 + 3"); a != b {
+		t.Errorf("small integers are not shared, got %p and %p", a, b)
+	}
+	if a, b := testEval("-128"), testEval("-100 - 28"); a != b {
+		t.Errorf("small integers are not shared, got %p and %p", a, b)
+	}
+	testIntegerObject(t, testEval("1024 + 1"), 1025)
+	testIntegerObject(t, testEval("-128 - 1"), -129)
+
+	if s := testEval(`""`); s != EMPTY {
+		t.Errorf("empty string is not shared, got %p", s)
+	}
+	if s := testEval(`"" + ""`); s != EMPTY {
+		t.Errorf("empty string is not shared, got %p", s)
+	}
+}
+
+func testIntegerArray(t *testing.T, o obj.Object, expected []int) bool {
+	array, ok := o.(*obj.Array)
+	if !ok {
+		t.Errorf("obj not Array. got=%T (%+v)", o, o)
+		return false
+	}
+
+	if len(array.Elements) != len(expected) {
+		t.Errorf("wrong num of elements. want=%d, got=%d",
+			len(expected), len(array.Elements))
+		return false
+	}
+
+	for i, expectedElem := range expected {
+		if !testIntegerObject(t, array.Elements[i], int64(expectedElem)) {
+			return false
+		}
+	}
+	return true
+}
+
+func testStringArray(t *testing.T, o obj.Object, expected []string) bool {
+	array, ok := o.(*obj.Array)
+	if !ok {
+		t.Errorf("obj not Array. got=%T (%+v)", o, o)
+		return false
+	}
+
+	if len(array.Elements) != len(expected) {
+		t.Errorf("wrong num of elements. want=%d, got=%d",
+			len(expected), len(array.Elements))
+		return false
+	}
+
+	for i, expectedElem := range expected {
+		if !testStringObject(t, array.Elements[i], expectedElem) {
+			return false
 		}
 	}
+	return true
+}
+
+func TestArrayValueSemantics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int
+	}{
+		{"let a = [1]; let b = append(a, 2); a;", []int{1}},
+		{"let a = [1]; let b = append(a, 2); b;", []int{1, 2}},
+		// Arrays derived from the same one don't share their storage.
+		{"let a = append([1, 2], 3); let b = append(a, 4); let c = append(a, 5); b;", []int{1, 2, 3, 4}},
+		{"let a = append([1, 2], 3); let b = append(a, 4); let c = append(a, 5); c;", []int{1, 2, 3, 5}},
+		// Closures see no change in the arrays they captured.
+		{"let a = [1]; let f = fn() { a }; let b = append(a, 2); f();", []int{1}},
+		{"let a = [1]; let f = fn(x) { append(a, x) }; f(2); f(3);", []int{1, 3}},
+		{"let f = fn(arr) { append(arr, 0) }; let a = [1]; f(a); a;", []int{1}},
+		// push changes the array in place.
+		{"let a = [1]; push(a, 2); a;", []int{1, 2}},
+		{"let a = [1]; let b = a; push(b, 2); a;", []int{1, 2}},
+		{"let a = [1]; let f = fn() { a }; push(a, 2); f();", []int{1, 2}},
+		// Empty arrays are distinct values.
+		{"let a = []; let b = []; push(a, 1); b;", []int{}},
+	}
+
+	for _, tt := range tests {
+		testIntegerArray(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+{
+	"one": 10 - 9,
+	two: 1 + 1,
+	"thr" + "ee": 6 / 2,
+	4: 4,
+	true: 5,
+	false: 6
+}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*obj.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[obj.HashKey]int64{
+		(&obj.String{Value: "one"}).HashKey():   1,
+		(&obj.String{Value: "two"}).HashKey():   2,
+		(&obj.String{Value: "three"}).HashKey(): 3,
+		(&obj.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                          5,
+		FALSE.HashKey():                         6,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+
+	if i := result.Inspect(); i != "{4: 4, false: 6, true: 5, one: 1, three: 3, two: 2}" {
+		t.Errorf("wrong Inspect, got %q", i)
+	}
+
+	// Integers are sorted by value whatever their size.
+	input = "{100000000000000000000: 1, 99999999999999999999: 2, 5: 3, -99999999999999999999: 4}"
+	expectedInspect := "{-99999999999999999999: 4, 5: 3, 99999999999999999999: 2, 100000000000000000000: 1}"
+	if i := testEval(input).Inspect(); i != expectedInspect {
+		t.Errorf("wrong Inspect, got %q", i)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{1: 1, 1: 2}[1]`, 2},
+		{`{"1": 1}[1]`, nil},
+		{`len({1: 1, 2: 2, 1: 3})`, 2},
+		{`{"name": "Monkey"}[fn(x) { x }]`, "unusable as hash key: FUNCTION"},
+		{`{[1]: 1}`, "unusable as hash key: ARRAY"},
+		{`{1: 1 + true}`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func testErrorObject(t *testing.T, o obj.Object, expected string) bool {
+	errObj, ok := o.(*obj.Error)
+	if !ok {
+		t.Errorf("object is not Error. got=%T (%+v)", o, o)
+		return false
+	}
+	if errObj.Msg != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Msg)
+		return false
+	}
+	return true
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, []int{2, 4, 6}},
+		{`map([], fn(x) { x })`, []int{}},
+		{`map([[1], [2, 3]], len)`, []int{1, 2}},
+		{`map([1], fn(x) { x + true })`, "type mismatch: INTEGER + BOOLEAN"},
+		{`map([1], fn(x, y) { x })`, "wrong number of arguments: got 1, want 2"},
+		{`map([1], 1)`, "map: second argument must be a function, got INTEGER"},
+		{`map(1, len)`, "map: first argument must be an array, got INTEGER"},
+		{`map([1])`, "map: wrong number of arguments: got 1, want 2"},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, []int{3, 4}},
+		{`filter([1, 2], fn(x) { false })`, []int{}},
+		{`filter([1, 2], fn(x) { return x / 0; })`, "division by zero"},
+		{`reduce([1, 2, 3], fn(acc, x) { acc + x })`, 6},
+		{`reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)`, 16},
+		{`reduce([], fn(acc, x) { acc + x })`, nil},
+		{`reduce([], fn(acc, x) { acc + x }, 0)`, 0},
+		{`reduce([1, 2], fn(acc, x) { acc + x }, 0, 1)`, "reduce: wrong number of arguments: got 4, want 2 to 3"},
+		{`sort_by([3, 1, 2], fn(x) { x })`, []int{1, 2, 3}},
+		{`sort_by([3, 1, 2], fn(x) { -x })`, []int{3, 2, 1}},
+		{`sort_by([[1], []], first)`, "sort_by: keys must be integers or strings, got NULL"},
+		{`map(sort_by([[2, 1], [1, 2], [2, 3], [1, 4]], fn(x) { x[0] }), last)`, []int{2, 4, 1, 3}},
+		{`map(sort_by([1, 22, 3], fn(x) { if (x > 10) { "b" } else { "a" } }), fn(x) { x })`, []int{1, 3, 22}},
+		{`sort_by([2, 1], fn(x) { x * 99999999999999999999 })`, []int{1, 2}},
+		{`sort_by([3, 1, 2], fn(x) { if (x == 2) { 99999999999999999999 } else { x } })`, []int{1, 3, 2}},
+		{`sort_by([3, 1, 2], fn(x) { if (x == 2) { -99999999999999999999 } else { x } })`, []int{2, 1, 3}},
+		{`sort_by([1, 2], fn(x) { true })`, "sort_by: keys must be integers or strings, got BOOLEAN"},
+		{`sort_by([1, 2], fn(x) { if (x == 1) { 1 } else { "a" } })`, "sort_by: mismatched keys: INTEGER and STRING"},
+		{`any([1, 2, 3], fn(x) { x == 2 })`, true},
+		{`any([1, 2, 3], fn(x) { x == 4 })`, false},
+		{`any([], fn(x) { true })`, false},
+		{`all([1, 2, 3], fn(x) { x > 0 })`, true},
+		{`all([1, 2, 3], fn(x) { x > 1 })`, false},
+		{`all([], fn(x) { false })`, true},
+		{`find([1, 2, 3, 4], fn(x) { x > 2 })`, 3},
+		{`find([1, 2], fn(x) { x > 2 })`, nil},
+		{`len(group_by([1, 2, 3, 4, 5], fn(x) { x / 2 }))`, 3},
+		{`group_by([1, 2, 3, 4, 5], fn(x) { x / 2 })[1]`, []int{2, 3}},
+		{`group_by([1, 2], fn(x) { [x] })`, "group_by: unusable as hash key: ARRAY"},
+		// The callbacks see the enclosing environment.
+		{`let n = 10; map([1, 2], fn(x) { x + n })`, []int{11, 12}},
+		{`let f = fn(k) { filter([1, 2, 3], fn(x) { x != k }) }; f(2)`, []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		case []int:
+			testIntegerArray(t, evaluated, expected)
+		}
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("héllo")`, 6},
+		{`rune_len("héllo")`, 5},
+		{`rune_len("")`, 0},
+		{`rune_len(1)`, "rune_len: first argument must be a string, got INTEGER"},
+		{`join(split("a,b,,c", ","), "|")`, "a|b||c"},
+		{`len(split("a,b,,c", ","))`, 4},
+		{"join(split(\"  a  b\tc\n\"), \"|\")", "a|b|c"},
+		{`join(split("日本語", ""), " ")`, "日 本 語"},
+		{`split("", ",")[0]`, ""},
+		{`join(["a", "b"])`, "ab"},
+		{`join([], ",")`, ""},
+		{`join([1], ",")`, "join: element 0 must be a string, got INTEGER"},
+		{"trim(\"  hi \n\")", "hi"},
+		{"trim(\"\u00a0hi\u2003\")", "hi"},
+		{`trim("xxhixx", "x")`, "hi"},
+		{`upper("héllo")`, "HÉLLO"},
+		{`lower("ÀBC")`, "àbc"},
+		{`replace("aaa", "a", "b")`, "bbb"},
+		{`replace("aaa", "a", "b", 2)`, "bba"},
+		{`replace("aaa", 1, "b")`, "replace: second argument must be a string, got INTEGER"},
+		{`starts_with("monkey", "mon")`, true},
+		{`starts_with("monkey", "key")`, false},
+		{`ends_with("monkey", "key")`, true},
+		{`ends_with("monkey", 1)`, "ends_with: second argument must be a string, got INTEGER"},
+		{`index("héllo", "llo")`, 2},
+		{`index("hello", "z")`, -1},
+		{`index("hello", 1)`, "index: second argument must be a string, got INTEGER"},
+		{`find("hello", fn(c) { c == "l" })`, "find: first argument must be an array, got STRING"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`repeat("ab", -1)`, "repeat: negative count: -1"},
+		{`repeat("ab", 9223372036854775807)`, "repeat: result too long"},
+		{`repeat("", 9223372036854775807)`, ""},
+		{`pad_left("a", 9223372036854775807)`, "pad_left: result too long"},
+		{`pad_right("a", 9223372036854775807, "é")`, "pad_right: result too long"},
+		{`pad_left("é", 3)`, "  é"},
+		{`pad_right("é", 3, "·")`, "é··"},
+		{`pad_left("hello", 3)`, "hello"},
+		{`pad_left("a", 3, "ab")`, "pad_left: padding must be a single character, got \"ab\""},
+		{`format("%d + %d = %d", 1, 2, 3)`, "1 + 2 = 3"},
+		{`format("%5s|%-5s|", "日本", "ab")`, "   日本|ab   |"},
+		{`format("%q %t %x %05d %%", "a", true, 255, 42)`, `"a" true ff 00042 %`},
+		{`format("%v %v", [1, "a"], first([]))`, "[1, a] null"},
+		{`let a = [1]; push(a, a); format("%v", a)`, "[1, [...]]"},
+		{`let a = [1]; push(a, {"a": a}); format("%v", [a])`, "[[1, {a: [...]}]]"},
+		{`let a = [1]; format("%v", [a, a])`, "[[1], [1]]"},
+		{`format("%d", "a")`, "format: %d requires an integer, got STRING"},
+		{`format("%s %s", "a")`, "format: missing argument for %s"},
+		{`format("%s", "a", "b")`, "format: too many arguments: got 2, want 1"},
+		{`format("%y", 1)`, "format: unknown verb %y"},
+		{`format("%é", 1)`, "format: unknown verb %é"},
+		{`format("%5日|%s", 1, "a")`, "format: unknown verb %日"},
+		{`format("50%")`, "format: missing verb at the end of \"50%\""},
+		{`format()`, "format: wrong number of arguments: got 0, want at least 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if errObj, ok := evaluated.(*obj.Error); ok {
+				if errObj.Msg != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, errObj.Msg)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestIOBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		stdin    string
+		expected interface{}
+		stdout   string
+	}{
+		{`puts("hello", 1, [true])`, "", nil, "hello\n1\n[true]\n"},
+		{`puts()`, "", nil, ""},
+		{`print("a", 1); print("b")`, "", nil, "a 1b"},
+		{`println("a", [1, 2]); println()`, "", nil, "a [1, 2]\n\n"},
+		{`input("name? ")`, "monkey\nrest\n", "monkey", "name? "},
+		{`input() + input()`, "a\r\nb", "ab", ""},
+		{`let a = input(); input()`, "a\n", nil, ""},
+		{`input()`, "", nil, ""},
+		{`input(1)`, "", "input: first argument must be a string, got INTEGER", ""},
+		{`input("a", "b")`, "", "input: wrong number of arguments: got 2, want 0 to 1", ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+
+		in := New()
+		in.Stdout = &out
+		in.Stdin = strings.NewReader(tt.stdin)
+		evaluated := in.Eval(parser.New(lexer.Lex(tt.input)).Parse(), obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if err, ok := evaluated.(*obj.Error); ok {
+				if err.Msg != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Msg)
+				}
+				break
+			}
+			testStringObject(t, evaluated, expected)
+		}
+
+		if out.String() != tt.stdout {
+			t.Errorf("%s: wrong output. expected=%q, got=%q", tt.input, tt.stdout, out.String())
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestIOBuiltinsWriteError(t *testing.T) {
+	in := New()
+	in.Stdout = failingWriter{}
+
+	evaluated := in.Eval(parser.New(lexer.Lex(`println("a")`)).Parse(), obj.NewEnv())
+	testErrorObject(t, evaluated, "println: broken pipe")
+}
+
+func TestJSONBuiltins(t *testing.T) {
+	// String literals can't contain quotes, so the JSON documents are bound
+	// to src.
+	tests := []struct {
+		input    string
+		src      string
+		expected interface{}
+	}{
+		{`json_encode(if (false) { 1 })`, "", "null"},
+		{`json_encode([1, "a", true, 1.5, 2.0])`, "", `[1,"a",true,1.5,2.0]`},
+		{`json_encode({"b": [1], "a": {"c": []}})`, "", `{"a":{"c":[]},"b":[1]}`},
+		{`json_encode(src)`, `<"a&b">`, `"<\"a&b\">"`},
+		{`json_encode(99999999999999999999)`, "", "99999999999999999999"},
+		{`json_encode({"a": [1, 2]}, 2)`, "", "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{`json_encode([1], "--")`, "", "[\n--1\n]"},
+		{`json_encode([])`, "", "[]"},
+		{`json_encode({})`, "", "{}"},
+		{`json_encode(fn(x) { x })`, "", "json_encode: cannot encode FUNCTION"},
+		{`json_encode([1, {"f": len}])`, "", "json_encode: cannot encode BUILTIN"},
+		{`json_encode(error("x"))`, "", "json_encode: cannot encode ERROR"},
+		{`json_encode({1: 2})`, "", "json_encode: object keys must be strings, got INTEGER"},
+		{`json_encode(1e308 * 10)`, "", "json_encode: unsupported value: +Inf"},
+		{`let a = [1]; push(a, a); json_encode(a)`, "", "json_encode: array contains itself"},
+		{`let a = [1]; json_encode([a, a])`, "", "[[1],[1]]"},
+		{`json_encode(1, -1)`, "", "json_encode: negative indent: -1"},
+		{`json_encode([1], 16)`, "", "[\n                1\n]"},
+		{`json_encode([1], 9223372036854775807)`, "", "json_encode: indent too large: 9223372036854775807, want at most 16"},
+		{`json_encode(1, true)`, "", "json_encode: second argument must be an integer or a string, got BOOLEAN"},
+		{`json_decode(src)`, "42", 42},
+		{`json_decode(src)`, "-1.5e2", -150.0},
+		{`json_decode(src)`, "true", true},
+		{`json_decode(src)`, "null", nil},
+		{`json_decode(src)`, `"hi"`, "hi"},
+		{`json_decode(src)[1]`, "[1, [2, 3]]", []int{2, 3}},
+		{`json_decode(src)["a"]["b"]`, `{"a": {"b": [1, 2]}}`, []int{1, 2}},
+		{`json_decode(src) == 18446744073709551616`, "18446744073709551616", true},
+		{`json_decode(src) == {"x": 1, "y": [true, 2.5]}`, `{"x": 1, "y": [true, 2.5]}`, true},
+		{`let v = {"a": [1, 2.5, "s", false]}; json_decode(json_encode(v)) == v`, "", true},
+		{`json_encode(json_decode(src))`, `{"b": null, "a": "\u00e8"}`, `{"a":"è","b":null}`},
+		{`json_decode(src)`, "[1,", "json_decode: unexpected EOF"},
+		{`json_decode(src)`, "1 2", "json_decode: unexpected data after the value"},
+		{`json_decode(src)`, `{"a": 1e999}`, "json_decode: number out of range: 1e999"},
+		{`json_decode(1)`, "", "json_decode: first argument must be a string, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		env := obj.NewEnv()
+		env.Set("src", &obj.String{Value: tt.src})
+		evaluated := Eval(parser.New(lexer.Lex(tt.input)).Parse(), env)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case []int:
+			testIntegerArray(t, evaluated, expected)
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestRegexpBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match("\d+", "ab 12 34")`, []string{"12"}},
+		{`match("(\w+)@(\w+)", "mail: bob@host")`, []string{"bob@host", "bob", "host"}},
+		{`match("a(x)?b", "ab")[1]`, nil},
+		{`match("\d", "abc")`, nil},
+		{`match("(?P<y>\d{4})-(?P<m>\d{2})", "on 2021-03")["y"]`, "2021"},
+		{`len(match("(?P<y>\d{4})-(\d{2})", "on 2021-03"))`, 1},
+		{`find_all("\d+", "a1 b22 c333")`, []string{"1", "22", "333"}},
+		{`find_all("\d+", "a1 b22 c333", 2)`, []string{"1", "22"}},
+		{`find_all("\d+", "abc")`, []string{}},
+		{`find_all("(\w)=(\d)", "a=1 b=2")[1]`, []string{"b=2", "b", "2"}},
+		{`map(find_all("(?P<k>\w)=\d", "a=1 b=2"), fn(m) { m["k"] })`, []string{"a", "b"}},
+		{`replace_re("\s+", "a  b 	 c", " ")`, "a b c"},
+		{`replace_re("(\w+)@(\w+)", "bob@host", "$2 at $1")`, "host at bob"},
+		{`replace_re("(?P<n>\d+)", "a1b2", "<${n}>")`, "a<1>b<2>"},
+		{`replace_re("\d+", "a1b22", fn(m) { repeat("x", len(m)) })`, "axbxx"},
+		{`replace_re("(\d)(\d)", "12 34", fn(m) { m[2] + m[1] })`, "21 43"},
+		{`replace_re("\d", "a1", upper)`, "a1"},
+		{`replace_re("\d", "a1", fn(m) { 1 })`, "replace_re: replacement must be a string, got INTEGER"},
+		{`replace_re("\d", "a1", fn(m) { 1 / 0 })`, "division by zero"},
+		{`replace_re("\d", "a1", 1)`, "replace_re: third argument must be a string or a function, got INTEGER"},
+		{`split_re("\s*,\s*", "a , b,c")`, []string{"a", "b", "c"}},
+		{`split_re(",", "a,b,c", 2)`, []string{"a", "b,c"}},
+		{`split_re(",", "")`, []string{""}},
+		{`match("(a", "a")`, "match: error parsing regexp: missing closing ): `(a`"},
+		{`find_all("[", "a")`, "find_all: error parsing regexp: missing closing ]: `[`"},
+		{`match(1, "a")`, "match: first argument must be a string, got INTEGER"},
+		{`split_re("a", 1)`, "split_re: second argument must be a string, got INTEGER"},
+		{`find_all("a", "a", "b")`, "find_all: third argument must be an integer, got STRING"},
+		{`match("a")`, "match: wrong number of arguments: got 1, want 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case []string:
+			testStringArray(t, evaluated, expected)
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestRegexpCache(t *testing.T) {
+	in := New()
+	in.Eval(parser.New(lexer.Lex(`match("a+", "aa"); find_all("a+", "a"); split_re("b", "aba")`)).Parse(), obj.NewEnv())
+	if len(in.regexps) != 2 {
+		t.Errorf("wrong number of cached regexps. expected=2, got=%d", len(in.regexps))
+	}
+
+	for i := 0; i <= maxRegexps; i++ {
+		in.regexpArg("match", []obj.Object{stoo(strconv.Itoa(i))}, 0)
+	}
+	if len(in.regexps) > maxRegexps {
+		t.Errorf("regexp cache grew past %d, got=%d", maxRegexps, len(in.regexps))
+	}
+}
+
+func TestRandomBuiltins(t *testing.T) {
+	const program = `[
+		rand_int(0, 1000000),
+		rand_int(-9223372036854775807 - 1, 9223372036854775807),
+		rand_float(),
+		shuffle([1, 2, 3, 4, 5, 6, 7, 8]),
+		choice(["a", "b", "c", "d"])
+	]`
+
+	evalSeeded := func(seed int64) string {
+		in := New()
+		in.Seed(seed)
+		return in.Eval(parser.New(lexer.Lex(program)).Parse(), obj.NewEnv()).Inspect()
+	}
+
+	// The same seed gives the same values, independently of the other
+	// interpreters.
+	a := evalSeeded(42)
+	evalSeeded(7)
+	if b := evalSeeded(42); a != b {
+		t.Errorf("same seed gave different values: %s and %s", a, b)
+	}
+	if c := evalSeeded(43); a == c {
+		t.Errorf("different seeds gave the same values: %s", a)
+	}
+
+	in := New()
+	for i := 0; i < 100; i++ {
+		prog := parser.New(lexer.Lex(`[rand_int(-2, 3), rand_float(), choice([1, 2]), sort_by(shuffle([3, 1, 2]), fn(x) { x })]`)).Parse()
+		res := in.Eval(prog, obj.NewEnv()).(*obj.Array).Elements
+
+		if n := res[0].(*obj.Integer).Value; n < -2 || n >= 3 {
+			t.Errorf("rand_int(-2, 3) out of range: %d", n)
+		}
+		if f := res[1].(*obj.Float).Value; f < 0 || f >= 1 {
+			t.Errorf("rand_float() out of range: %g", f)
+		}
+		if n := res[2].(*obj.Integer).Value; n != 1 && n != 2 {
+			t.Errorf("choice([1, 2]) returned %d", n)
+		}
+		testIntegerArray(t, res[3], []int{1, 2, 3})
+	}
+
+	failures := []struct {
+		input    string
+		expected string
+	}{
+		{"rand_int(3, 3)", "rand_int: empty range from 3 to 3"},
+		{"rand_int(1)", "rand_int: wrong number of arguments: got 1, want 2"},
+		{"rand_int(1.5, 3)", "rand_int: first argument must be an integer, got FLOAT"},
+		{"rand_float(1)", "rand_float: wrong number of arguments: got 1, want 0"},
+		{"shuffle(1)", "shuffle: first argument must be an array, got INTEGER"},
+		{"choice([])", "choice: empty array"},
+	}
+	for _, tt := range failures {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+
+	// Shuffling doesn't touch the original array.
+	testIntegerArray(t, testEval("let a = [1, 2, 3]; shuffle(a); a"), []int{1, 2, 3})
+}
+
+// In-memory file system write_file can write to.
+type memFS struct {
+	fstest.MapFS
+}
+
+func (m memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.MapFS[name] = &fstest.MapFile{Data: data, Mode: perm}
+	return nil
+}
+
+func TestEnvBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		status   int
+	}{
+		{`args()`, []string{"a", "-b"}, -1},
+		{`env("HOME")`, "/home/monkey", -1},
+		{`env("EMPTY", "x")`, "", -1},
+		{`env("PATH")`, nil, -1},
+		{`env("PATH", "/bin")`, "/bin", -1},
+		{`env(1)`, "env: first argument must be a string, got INTEGER", -1},
+		{`exit(3); 1`, "exit status 3", 3},
+		{`exit()`, "exit status 0", 0},
+		{`let f = fn() { exit(1) }; f(); 2`, "exit status 1", 1},
+		{`try { exit(4) } catch (e) { 0 }`, "exit status 4", 4},
+		{`throw error("exit status 5", "exit")`, "exit status 5", -1},
+		{`exit(256)`, "exit: status out of range: 256", -1},
+		{`exit("1")`, "exit: first argument must be an integer, got STRING", -1},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.Args = []string{"a", "-b"}
+		in.Env = map[string]string{"HOME": "/home/monkey", "EMPTY": ""}
+		evaluated := in.Eval(parser.New(lexer.Lex(tt.input)).Parse(), obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case []string:
+			testStringArray(t, evaluated, expected)
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				break
+			}
+			testStringObject(t, evaluated, expected)
+		}
+
+		status, ok := in.ExitStatus(evaluated)
+		if ok != (tt.status >= 0) || ok && status != tt.status {
+			t.Errorf("%s: wrong exit status. expected=%d, got=%d (%t)", tt.input, tt.status, status, ok)
+		}
+	}
+}
+
+func TestHostEnv(t *testing.T) {
+	os.Setenv("MONKEY_TEST_A", "a")
+	os.Unsetenv("MONKEY_TEST_B")
+	defer os.Unsetenv("MONKEY_TEST_A")
+
+	env := HostEnv("MONKEY_TEST_A", "MONKEY_TEST_B")
+	if len(env) != 1 || env["MONKEY_TEST_A"] != "a" {
+		t.Errorf("wrong environment. expected=%v, got=%v", map[string]string{"MONKEY_TEST_A": "a"}, env)
+	}
+}
+
+// Clock that only moves when slept on.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestTimeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`now()`, 1600000000123},
+		{`let t = now(); sleep(1500); now() - t`, 1500},
+		{`sleep(0)`, nil},
+		{`format_time(now(), "2006-01-02 15:04:05.000")`, "2020-09-13 12:26:40.123"},
+		{`format_time(0, "2006-01-02T15:04:05Z07:00")`, "1970-01-01T00:00:00Z"},
+		{`format_time(-1, "2006-01-02 15:04:05.000")`, "1969-12-31 23:59:59.999"},
+		{`format_time(0, "15:04 MST", "Europe/Rome")`, "01:00 CET"},
+		{`format_time(now() + duration("36h"), "Jan 2")`, "Sep 15"},
+		{`parse_time("2020-09-13 12:26:40.123", "2006-01-02 15:04:05.000")`, 1600000000123},
+		{`parse_time("1970-01-01 01:00", "2006-01-02 15:04", "Europe/Rome")`, 0},
+		{`parse_time("1970-01-01T00:00:01+01:00", "2006-01-02T15:04:05Z07:00")`, -3599000},
+		{`let l = "2006-01-02"; format_time(parse_time("2021-03-04", l), l)`, "2021-03-04"},
+		{`duration("1h30m")`, 5400000},
+		{`duration("2.5s")`, 2500},
+		{`duration("-1ms")`, -1},
+		{`format_duration(5400000)`, "1h30m0s"},
+		{`format_duration(duration("90s") * 2)`, "3m0s"},
+		{`parse_time("x", "2006")`, `parse_time: parsing time "x" as "2006": cannot parse "x" as "2006"`},
+		{`format_time(0, "2006", "Nowhere/Nope")`, "format_time: unknown time zone: Nowhere/Nope"},
+		{`duration("1x")`, `duration: time: unknown unit "x" in duration "1x"`},
+		{`sleep(-1)`, "sleep: negative duration: -1"},
+		{`sleep(9223372036854775807)`, "sleep: duration out of range: 9223372036854775807"},
+		{`format_duration(-9223372036854775807)`, "format_duration: duration out of range: -9223372036854775807"},
+		{`format_time("now", "2006")`, "format_time: first argument must be an integer, got STRING"},
+		{`now(1)`, "now: wrong number of arguments: got 1, want 0"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.Clock = &fakeClock{now: time.Unix(1600000000, 123000000)}
+		evaluated := in.Eval(parser.New(lexer.Lex(tt.input)).Parse(), obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestFileBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`read_file("a.txt")`, "hello\n"},
+		{`read_file("./dir/../a.txt")`, "hello\n"},
+		{`read_lines("lines.txt")`, []string{"one", "two", "", "three"}},
+		{`read_lines("empty.txt")`, []string{}},
+		{`list_dir()`, []string{"a.txt", "dir", "empty.txt", "lines.txt"}},
+		{`list_dir("dir")`, []string{"b.txt", "c.txt"}},
+		{`[exists("a.txt"), exists("dir"), exists("nope.txt")]`, []bool{true, true, false}},
+		{`write_file("new.txt", "data"); read_file("new.txt")`, "data"},
+		{`write_file("a.txt", "bye"); read_file("a.txt")`, "bye"},
+		{`read_file("nope.txt")`, "read_file: open nope.txt: file does not exist"},
+		{`read_file("../a.txt")`, "read_file: path outside the root: ../a.txt"},
+		{`read_file("dir/../../a.txt")`, "read_file: path outside the root: dir/../../a.txt"},
+		{`read_file("/etc/passwd")`, "read_file: path outside the root: /etc/passwd"},
+		{`write_file("../x.txt", "")`, "write_file: path outside the root: ../x.txt"},
+		{`exists("..")`, "exists: path outside the root: .."},
+		{`list_dir("../..")`, "list_dir: path outside the root: ../.."},
+		{`read_lines("/a.txt")`, "read_lines: path outside the root: /a.txt"},
+		{`write_file("a.txt", 1)`, "write_file: second argument must be a string, got INTEGER"},
+		{`read_file(1)`, "read_file: first argument must be a string, got INTEGER"},
+		{`try { read_file("nope.txt") } catch (e) { e["kind"] }`, "runtime"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.FS = memFS{fstest.MapFS{
+			"a.txt":     {Data: []byte("hello\n")},
+			"lines.txt": {Data: []byte("one\r\ntwo\n\nthree\n")},
+			"empty.txt": {Data: []byte{}},
+			"dir/b.txt": {Data: []byte("b")},
+			"dir/c.txt": {Data: []byte("c")},
+		}}
+		evaluated := in.Eval(parser.New(lexer.Lex(tt.input)).Parse(), obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case []string:
+			testStringArray(t, evaluated, expected)
+		case []bool:
+			arr, ok := evaluated.(*obj.Array)
+			if !ok {
+				t.Errorf("%s: object is not Array, got %T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			for i, b := range expected {
+				testBooleanObject(t, arr.Elements[i], b)
+			}
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+
+	// Without a file system the builtins fail, and they can't write to a
+	// read-only one.
+	in := New()
+	prog := parser.New(lexer.Lex(`read_file("a.txt")`)).Parse()
+	testErrorObject(t, in.Eval(prog, obj.NewEnv()), "read_file: no file system available")
+	prog = parser.New(lexer.Lex(`list_dir()`)).Parse()
+	testErrorObject(t, in.Eval(prog, obj.NewEnv()), "list_dir: no file system available")
+
+	in.FS = fstest.MapFS{"a.txt": {Data: []byte("a")}}
+	prog = parser.New(lexer.Lex(`write_file("a.txt", "b")`)).Parse()
+	testErrorObject(t, in.Eval(prog, obj.NewEnv()), "write_file: read-only file system")
+}
+
+func TestDirFS(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"root/a.txt":     "a",
+		"root/sub/b.txt": "b",
+		"secret.txt":     "secret",
+	})
+	root := filepath.Join(dir, "root")
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "leak")); err != nil {
+		t.Skip("symbolic links not supported:", err)
+	}
+	if err := os.Symlink(dir, filepath.Join(root, "up")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub/b.txt", filepath.Join(root, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := DirFS(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`read_file("a.txt")`, "a"},
+		{`read_file("sub/b.txt")`, "b"},
+		{`read_file("b")`, "b"},
+		{`list_dir()`, []string{"a.txt", "b", "leak", "sub", "up"}},
+		{`write_file("sub/c.txt", "c"); read_lines("sub/c.txt")`, []string{"c"}},
+		{`read_file("leak")`, "read_file: open leak: permission denied"},
+		{`read_file("up/secret.txt")`, "read_file: open up/secret.txt: permission denied"},
+		{`write_file("up/new.txt", "x")`, "write_file: write up/new.txt: permission denied"},
+		{`write_file("leak", "x")`, "write_file: write leak: permission denied"},
+		{`read_file("nope.txt")`, "read_file: open nope.txt: no such file or directory"},
+		{`write_file("nope/x.txt", "x")`, "write_file: write nope/x.txt: no such file or directory"},
+		{`exists("up/secret.txt")`, "exists: open up/secret.txt: permission denied"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.FS = fsys
+		evaluated := in.Eval(parser.New(lexer.Lex(tt.input)).Parse(), obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case []string:
+			testStringArray(t, evaluated, expected)
+		case string:
+			if _, ok := evaluated.(*obj.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "secret.txt")); err != nil || string(b) != "secret" {
+		t.Errorf("file outside the root changed: %q, %v", b, err)
+	}
+}
+
+// Writes files to a new temporary directory and returns its path.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestModules(t *testing.T) {
+	lib := map[string]string{
+		"lib/math.mk": `
+			import "util";
+			export let square = fn(x) { util.mul(x, x) };
+			export let answer = 42;
+			let hidden = 1;
+			puts("loaded");
+		`,
+		"lib/util.mk": `export let mul = fn(a, b) { a * b };`,
+		"a.mk":        `import "b"; export let a = 1;`,
+		"b.mk":        `import "a"; export let b = 2;`,
+		"self.mk":     `import "self"; 1`,
+		"broken.mk":   `let = 1;`,
+		"fails.mk":    `1 / 0;`,
+		"std/seq.mk":  `export let seq = fn(n) { if (n == 0) { [] } else { push(seq(n - 1), n) } };`,
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+		stdout   string
+	}{
+		{`import "lib/math"; math.square(3)`, 9, "loaded\n"},
+		{`import "lib/math.mk"; math["answer"]`, 42, "loaded\n"},
+		{`import "lib/math" as m; m.answer`, 42, "loaded\n"},
+		{`let f = fn() { import "lib/math" as m; m.answer }; f()`, 42, "loaded\n"},
+		// Modules are evaluated once and shared by all their importers.
+		{`import "lib/math"; import "lib/math" as m; m == math`, true, "loaded\n"},
+		{`import "lib/math"; math.hidden`, "math: undefined export: hidden", "loaded\n"},
+		{`import "lib/math"; util`, "identifier not found: util", "loaded\n"},
+		{`import "lib/util"; util.mul(2, 3)`, 6, ""},
+		{`import "seq"; seq.seq(3)`, []int{1, 2, 3}, ""},
+		{`import "nope"`, "import: module not found: nope", ""},
+		{`import "a"`, "import cycle: a.mk -> b.mk -> a.mk", ""},
+		{`import "self"`, "import cycle: self.mk -> self.mk", ""},
+		{`import "broken"`, "broken.mk: expected next token to be IDENT, got = instead", ""},
+		{`import "fails"`, "division by zero", ""},
+		{`import "lib/math"; math + 1`, "type mismatch: MODULE + INTEGER", "loaded\n"},
+		{`import "lib/../lib/util"; util.mul(2, 3)`, 6, ""},
+		{`import "/etc/passwd"`, "import: path outside the root: /etc/passwd", ""},
+		{`import "../main"`, "import: path outside the root: ../main", ""},
+		{`import "lib/../../main"`, "import: path outside the root: lib/../../main", ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+
+		files := map[string]string{"main.mk": tt.input}
+		for name, src := range lib {
+			files[name] = src
+		}
+		dir := writeFiles(t, files)
+
+		root, err := DirFS(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := New()
+		in.Stdout = &out
+		in.FS = root
+		in.SearchPath = []string{"std"}
+		evaluated := in.EvalFile("main.mk", obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case []int:
+			testIntegerArray(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+
+		if out.String() != tt.stdout {
+			t.Errorf("%s: wrong output. expected=%q, got=%q", tt.input, tt.stdout, out.String())
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		profile  Profile
+		input    string
+		expected interface{}
+	}{
+		{Pure, `puts("hi")`, "identifier not found: puts"},
+		{Pure, `read_file("a.txt")`, "identifier not found: read_file"},
+		{Pure, `len("abc")`, 3},
+		{Pure, `now()`, "identifier not found: now"},
+		{Pure, `env("HOME")`, "identifier not found: env"},
+		{Pure, `args()`, "identifier not found: args"},
+		{Pure, `exit(1)`, "identifier not found: exit"},
+		{Pure, `import "lib"; lib.x`, "import: lib: not allowed by the pure profile"},
+		{Pure, `import "std/math"; math.factorial(3)`, 6},
+		{ReadOnly, `len(read_file("a.txt"))`, 5},
+		{ReadOnly, `write_file("a.txt", "")`, "identifier not found: write_file"},
+		{ReadOnly, `import "lib"; lib.x`, 1},
+		{ReadOnly, `sleep(0); 1`, 1},
+		{ReadOnly, `env("HOME")`, "/home/monkey"},
+		{Full, `write_file("b.txt", "b"); read_file("b.txt")`, "b"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.SetProfile(tt.profile)
+		in.Env = map[string]string{"HOME": "/home/monkey"}
+		in.FS = memFS{fstest.MapFS{
+			"a.txt":  {Data: []byte("hello")},
+			"lib.mk": {Data: []byte(`export let x = 1;`)},
+		}}
+		evaluated := in.Eval(parser.New(lexer.Lex(tt.input)).Parse(), obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if s, ok := evaluated.(*obj.String); ok {
+				if s.Value != expected {
+					t.Errorf("%s: wrong value. expected=%q, got=%q", tt.input, expected, s.Value)
+				}
+				continue
+			}
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+
+	if _, ok := LookupProfile("read-only"); !ok {
+		t.Errorf("profile read-only not found")
+	}
+	if _, ok := LookupProfile("nope"); ok {
+		t.Errorf("profile nope found")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	const loop = `let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } };`
+	// Builds arrays with 2^n elements in n steps, sharing their halves.
+	const dag = `let d = fn(a, n) { if (n == 0) { a } else { d([a, a], n - 1) } };`
+	const big = dag + `let a = flatten(d([1], 12)); let b = flatten(d([1], 12));`
+
+	tests := []struct {
+		limits   Limits
+		input    string
+		expected interface{}
+	}{
+		{Limits{MaxSteps: 1000}, loop + `f(10)`, 0},
+		{Limits{MaxSteps: 1000}, loop + `f(1000)`, "step limit exceeded: 1000"},
+		{Limits{MaxDepth: 100}, loop + `f(99)`, 0},
+		{Limits{MaxDepth: 100}, loop + `f(100)`, "call depth limit exceeded: 100"},
+		{Limits{MaxDepth: 100}, `let f = fn() { f() }; f()`, "call depth limit exceeded: 100"},
+		{Limits{Timeout: time.Nanosecond}, loop + `f(5000)`, "timeout exceeded: 1ns"},
+		{Limits{Timeout: time.Hour}, `sleep(7200000)`, "timeout exceeded: 1h0m0s"},
+		{Limits{MaxLen: 10}, `"abcde" + "fghij"`, "abcdefghij"},
+		{Limits{MaxLen: 10}, `"abcde" + "fghijk"`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `let d = fn(s) { d(s + s) }; d("a")`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `repeat("ab", 5)`, "ababababab"},
+		{Limits{MaxLen: 10}, `repeat("ab", 6)`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `repeat("ab", 9223372036854775807)`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `pad_left("a", 11)`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `len(push([1, 2, 3, 4, 5, 6, 7, 8, 9], 10))`, 10},
+		{Limits{MaxLen: 10}, `push([1, 2, 3, 4, 5, 6, 7, 8, 9, 10], 11)`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `let a = [1, 2]; len(flatten([a, a, a, a, a]))`, 10},
+		{Limits{MaxLen: 10}, `let d = fn(a, n) { if (n == 0) { a } else { d([a, a], n - 1) } }; flatten(d([1], 40))`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `pow(2, 70) > 0`, true},
+		{Limits{MaxLen: 10}, `pow(2, 100000000)`, "size limit exceeded: 10"},
+		{Limits{MaxLen: 10}, `pow(1, 100000000)`, 1},
+		{Limits{MaxLen: 10}, `try { repeat("ab", 6) } catch (e) { 0 }`, "size limit exceeded: 10"},
+		// The builtins and the operators account for the elements they visit.
+		{Limits{MaxSteps: 30000}, big + `len(a)`, 4096},
+		{Limits{MaxSteps: 30000}, big + `sum(a)`, 4096},
+		{Limits{MaxSteps: 20000}, dag + `flatten(d([1], 20))`, "step limit exceeded: 20000"},
+		{Limits{MaxSteps: 27000}, big + `a == b`, "step limit exceeded: 27000"},
+		{Limits{MaxSteps: 27000}, big + `a < b`, "step limit exceeded: 27000"},
+		{Limits{MaxSteps: 27000}, big + `contains([[0], b], a)`, "step limit exceeded: 27000"},
+		{Limits{MaxSteps: 27000}, big + `sum(a) + sum(b)`, "step limit exceeded: 27000"},
+		{Limits{MaxSteps: 27000}, big + `index_of(b, 2)`, "step limit exceeded: 27000"},
+		{Limits{MaxSteps: 27000}, big + `json_encode(a)`, "step limit exceeded: 27000"},
+		{Limits{MaxSteps: 27000}, big + `sort_by(a, abs)`, "step limit exceeded: 27000"},
+		{Limits{MaxSteps: 5000}, dag + `format("%v", d([1], 2))`, "[[[1], [1]], [[1], [1]]]"},
+		{Limits{MaxSteps: 5000}, dag + `format("%v", d([1], 32))`, "step limit exceeded: 5000"},
+		{Limits{MaxSteps: 5000}, dag + `d([1], 32) == d([1], 32)`, true},
+		{Limits{MaxSteps: 5000}, dag + `d([1], 32) <= d([1], 32)`, true},
+		{Limits{MaxSteps: 1000}, loop + `try { f(1000) } catch (e) { 0 }`, "step limit exceeded: 1000"},
+		// Only the interpreter raises limit errors.
+		{Limits{MaxSteps: 1000}, `try { throw error("x", "limit") } catch (e) { 1 }`, 1},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.SetLimits(tt.limits)
+		evaluated := in.Eval(parser.New(lexer.Lex(tt.input)).Parse(), obj.NewEnv())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if s, ok := evaluated.(*obj.String); ok {
+				if s.Value != expected {
+					t.Errorf("%s: wrong value. expected=%q, got=%q", tt.input, expected, s.Value)
+				}
+				continue
+			}
+			if testErrorObject(t, evaluated, expected) && evaluated.(*obj.Error).Kind != "limit" {
+				t.Errorf("%s: wrong error kind. expected=%q, got=%q", tt.input, "limit", evaluated.(*obj.Error).Kind)
+			}
+		}
+	}
+}
+
+func TestModulesFS(t *testing.T) {
+	in := New()
+	testErrorObject(t, in.Eval(parser.New(lexer.Lex(`import "lib"`)).Parse(), obj.NewEnv()), "import: no file system available")
+	testIntegerObject(t, in.Eval(parser.New(lexer.Lex(`import "std/math"; math.factorial(3)`)).Parse(), obj.NewEnv()), 6)
+	testErrorObject(t, in.EvalFile("main.mk", obj.NewEnv()), "no file system available")
+
+	in.FS = memFS{fstest.MapFS{
+		"dir/main.mk": {Data: []byte(`import "util"; util.x`)},
+		"dir/util.mk": {Data: []byte(`export let x = 4;`)},
+		"bad.mk":      {Data: []byte(`let = 1;`)},
+	}}
+	testIntegerObject(t, in.EvalFile("dir/main.mk", obj.NewEnv()), 4)
+	testIntegerObject(t, in.EvalFile("./dir/../dir/main.mk", obj.NewEnv()), 4)
+	testErrorObject(t, in.EvalFile("../main.mk", obj.NewEnv()), "path outside the root: ../main.mk")
+	testErrorObject(t, in.EvalFile("nope.mk", obj.NewEnv()), "file does not exist")
+	testErrorObject(t, in.EvalFile("bad.mk", obj.NewEnv()), "expected next token to be IDENT, got = instead")
+}
+
+// The files are optimized before being evaluated, which must not change
+// their results.
+func TestEvalFileOptimized(t *testing.T) {
+	tests := []string{
+		"60 * 60 * 24",
+		"1 / 0",
+		"let f = fn(x) { x / (2 - 2) }; f(1)",
+		"let f = fn() { let d = 0; 1 / d }; f()",
+		"let f = fn() { let d = 60 * 60; d * 24 }; f()",
+		"if (1 > 2) { 1 } else { 2 / 0 }",
+		"try { 1 / 0 } catch (e) { e[\"position\"] }",
+		"5 + true",
+		"-true",
+		`"a" - "b"`,
+		"9223372036854775807 + 1",
+		"9223372036854775807 * 2 / 2",
+		"-(-9223372036854775807 - 1)",
+		"99999999999999999999 - 1",
+		"if (false) { 1 }",
+		"if (true) { }",
+		"let f = fn() { let k = 10; let g = fn(x) { x * k }; g(2) + k }; f()",
+		"let f = fn(n) { if (true) { return n * 2; }; n }; f(4)",
+		"let x = 1; let f = fn() { let y = x; let x = 2; y + x }; f()",
+		`let f = fn() { let k = "a"; let h = {k: 1, k + "b": 2, k: 3}; h[k] + len(h) }; f()`,
+	}
+
+	for _, input := range tests {
+		in := New()
+		in.FS = fstest.MapFS{"main.mk": {Data: []byte(input)}}
+		expected := testEval(input)
+		actual := in.EvalFile("main.mk", obj.NewEnv())
+
+		if expected == nil || actual == nil {
+			if expected != actual {
+				t.Errorf("%q: expected %v, got %v", input, expected, actual)
+			}
+			continue
+		}
+		if expected.Inspect() != actual.Inspect() {
+			t.Errorf("%q: expected %q, got %q", input, expected.Inspect(), actual.Inspect())
+		}
+		if e, ok := expected.(*obj.Error); ok {
+			if a, ok := actual.(*obj.Error); ok && e.Pos != a.Pos {
+				t.Errorf("%q: wrong error position. expected=%d, got=%d", input, e.Pos, a.Pos)
+			}
+		}
+	}
+
+	// Folded, the program is a single literal.
+	in := New()
+	in.FS = fstest.MapFS{"main.mk": {Data: []byte("60 * 60 * 24")}}
+	in.SetLimits(Limits{MaxSteps: 3})
+	testIntegerObject(t, in.EvalFile("main.mk", obj.NewEnv()), 86400)
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	const input = `
+let fib = fn(n) {
+	if (n < 2) {
+		return n;
+	}
+	fib(n - 1) + fib(n - 2);
+};
+fib(20);`
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		testEval(input)
+	}
+}
+
+func BenchmarkArithmetic(b *testing.B) {
+	const input = `
+let sum = fn(n, acc) {
+	if (n == 0) {
+		return acc;
+	}
+	sum(n - 1, acc + n - n / 2 * 2);
+};
+sum(500, 0);`
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		testEval(input)
+	}
 }
@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"math/big"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+func ftoo(f float64) *obj.Float {
+	return &obj.Float{Value: f}
+}
+
+// Reports whether o is a number, either an integer or a float.
+func isNumber(o obj.Object) bool {
+	return isInteger(o) || o.Type() == obj.FLOAT
+}
+
+// Returns the value of the number o as a float64, the closest one for the
+// integers that can't be represented exactly.
+func toFloat(o obj.Object) float64 {
+	switch o := o.(type) {
+	case *obj.Float:
+		return o.Value
+	case *obj.Integer:
+		return float64(o.Value)
+	case *obj.BigInt:
+		f, _ := new(big.Float).SetInt(o.Value).Float64()
+		return f
+	}
+	return 0
+}
+
+// Evaluates the infix expressions between two numbers where at least one is
+// a float, the other one is converted to a float.
+func evalFloatInfixExpr(op string, left, right obj.Object) obj.Object {
+	var l = toFloat(left)
+	var r = toFloat(right)
+
+	switch op {
+
+	case "+":
+		return ftoo(l + r)
+
+	case "-":
+		return ftoo(l - r)
+
+	case "*":
+		return ftoo(l * r)
+
+	case "/":
+		if r == 0 {
+			return newError("division by zero")
+		}
+		return ftoo(l / r)
+
+	case "==":
+		return btoo(l == r)
+
+	case "!=":
+		return btoo(l != r)
+
+	case "<":
+		return btoo(l < r)
+
+	case ">":
+		return btoo(l > r)
+
+	case "<=":
+		return btoo(l <= r)
+
+	case ">=":
+		return btoo(l >= r)
+
+	default:
+		lt := left.Type().String()
+		rt := right.Type().String()
+		return newError("unknown operator: %s %s %s", lt, op, rt)
+	}
+}
@@ -0,0 +1,105 @@
+package evaluator
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WriteFS is a file system write_file can write to.
+type WriteFS interface {
+	fs.FS
+	// WriteFile writes data to the file name, creating it with permissions
+	// perm if it doesn't exist and truncating it otherwise.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// Writable file system rooted at a directory of the host.
+type dirFS struct {
+	root string
+}
+
+// DirFS returns a file system over the directory dir. It rejects the paths
+// that lead outside of dir, symbolic links included.
+func DirFS(dir string) (WriteFS, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, err
+	}
+	return dirFS{root: root}, nil
+}
+
+// Returns the host path of the file name, which must be inside the root
+// once its symbolic links are followed. The file itself may not exist.
+func (d dirFS) resolve(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	p := filepath.Join(d.root, filepath.FromSlash(name))
+	real, err := filepath.EvalSymlinks(p)
+	if os.IsNotExist(err) {
+		// Check the directory the file would be created in.
+		var dir string
+		if dir, err = filepath.EvalSymlinks(filepath.Dir(p)); err == nil {
+			real = filepath.Join(dir, filepath.Base(p))
+		}
+	}
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: unwrap(err)}
+	}
+
+	if real != d.root && !strings.HasPrefix(real, d.root+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+	return real, nil
+}
+
+func (d dirFS) Open(name string) (fs.File, error) {
+	p, err := d.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrap(err)}
+	}
+	return f, nil
+}
+
+func (d dirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	p, err := d.resolve("write", name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(p, data, perm); err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: unwrap(err)}
+	}
+	return nil
+}
+
+// Returns the underlying error of the path errors, whose paths are the ones
+// of the host and must not be shown to the scripts.
+func unwrap(err error) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return pe.Err
+	}
+	return err
+}
+
+// Converts the path p given by a script into the name of a file in an
+// fs.FS, failing if it leads outside of the root.
+func fsPath(p string) (string, bool) {
+	name := path.Clean(p)
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return name, fs.ValidPath(name)
+}
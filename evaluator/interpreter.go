@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/NicoNex/monkey/ast"
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Interpreter evaluates Monkey programs and owns the state they share, like
+// the builtins that need to call back into the interpreter.
+type Interpreter struct {
+	// Stdout is where the printing builtins write, os.Stdout by default.
+	Stdout io.Writer
+	// Stdin is where input reads from, os.Stdin by default.
+	Stdin io.Reader
+	// FS is the file system the file builtins work on and the imports are
+	// read from, they fail if it's nil. The file builtins can write only if
+	// it's also a WriteFS.
+	FS fs.FS
+	// Clock is the source of the current time of the time builtins, the
+	// one of the host by default.
+	Clock Clock
+	// Args are the arguments of the script args returns.
+	Args []string
+	// Env holds the environment variables visible to the scripts, none by
+	// default. See HostEnv.
+	Env map[string]string
+	// SearchPath lists the directories of FS the imports are looked up in
+	// when they are not found relative to the importing file.
+	SearchPath []string
+
+	builtins map[string]*obj.Builtin
+	// Buffered reader over Stdin, rebuilt if Stdin changes.
+	stdin    *bufio.Reader
+	stdinSrc io.Reader
+	// Modules already imported by id, see evalFile.
+	modules map[string]*obj.Module
+	// Ids of the files being evaluated, innermost last.
+	loading []string
+	// Source of the random builtins, see Seed.
+	rand *rand.Rand
+	// Error returned by the last call to exit and its status code.
+	exit   *obj.Error
+	status int
+	// Regular expressions compiled by the regexp builtins by pattern.
+	regexps map[string]*regexp.Regexp
+
+	profile Profile
+	limits  Limits
+	// Whether any limit is set.
+	limited bool
+	usage   usage
+}
+
+// New returns an interpreter with the Full profile and no limits that uses
+// the standard input and output of the process. Its random number generator
+// is seeded with the current time.
+func New() *Interpreter {
+	var in = &Interpreter{
+		Stdout:  os.Stdout,
+		Stdin:   os.Stdin,
+		Clock:   systemClock{},
+		modules: make(map[string]*obj.Module),
+	}
+
+	in.SetProfile(Full)
+	in.Seed(time.Now().UnixNano())
+	return in
+}
+
+// Returns a builtin that calls fn with in as receiver.
+func (in *Interpreter) bind(fn boundFn) *obj.Builtin {
+	return &obj.Builtin{
+		Fn: func(args ...obj.Object) obj.Object {
+			return fn(in, args...)
+		},
+	}
+}
+
+// Returns the buffered reader over Stdin.
+func (in *Interpreter) reader() *bufio.Reader {
+	if in.stdin == nil || in.stdinSrc != in.Stdin {
+		in.stdin = bufio.NewReader(in.Stdin)
+		in.stdinSrc = in.Stdin
+	}
+	return in.stdin
+}
+
+// Eval evaluates node in env with a new interpreter. The node must have been
+// through resolver.Resolve, as parser.Parse does.
+func Eval(node ast.Node, env *obj.Env) obj.Object {
+	return New().Eval(node, env)
+}
@@ -0,0 +1,215 @@
+package evaluator
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/NicoNex/monkey/ast"
+	"github.com/NicoNex/monkey/lexer"
+	"github.com/NicoNex/monkey/obj"
+	"github.com/NicoNex/monkey/optimize"
+	"github.com/NicoNex/monkey/parser"
+	"github.com/NicoNex/monkey/stdlib"
+)
+
+const (
+	// Extension of the Monkey source files, added to the imported paths
+	// that have none.
+	ext = ".mk"
+	// Prefix of the paths of the modules in the standard library.
+	std = "std/"
+)
+
+// LoadStdlib imports all the modules of the standard library and binds each
+// of them to its name in env.
+func (in *Interpreter) LoadStdlib(env *obj.Env) obj.Object {
+	files, err := fs.Glob(stdlib.FS, "*"+ext)
+	if err != nil {
+		return newError("%v", err)
+	}
+
+	for _, f := range files {
+		mod := in.importModule(std + f)
+		if isError(mod) {
+			return mod
+		}
+		env.Set(mod.(*obj.Module).Name, mod)
+	}
+	return nil
+}
+
+// EvalFile evaluates the program in the file at path in FS, in env. The
+// imports in the file are resolved relative to its directory. The errors
+// don't name the file, callers know it already.
+func (in *Interpreter) EvalFile(path string, env *obj.Env) obj.Object {
+	if in.FS == nil {
+		return newError("no file system available")
+	}
+	name, ok := fsPath(path)
+	if !ok {
+		return newError("path outside the root: %s", path)
+	}
+	_, ret := in.evalFile("/"+name, env)
+	return ret
+}
+
+// Reads, parses, optimizes and evaluates the module with the given id in
+// env, keeping it on the stack of the files being loaded in the meantime.
+// The modules of the standard library are identified by their std/ path, the
+// others by their path in FS with a leading slash. The program is nil if the
+// file can't be read or parsed, the errors don't name it.
+func (in *Interpreter) evalFile(id string, env *obj.Env) (*ast.Program, obj.Object) {
+	var b []byte
+	var err error
+
+	if strings.HasPrefix(id, std) {
+		b, err = stdlib.FS.ReadFile(strings.TrimPrefix(id, std))
+	} else {
+		b, err = fs.ReadFile(in.FS, id[1:])
+	}
+	if err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			err = pe.Err
+		}
+		return nil, newError("%v", err)
+	}
+
+	p := parser.New(lexer.Lex(string(b)))
+	prog := p.Parse()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, newError("%s", errs[0])
+	}
+	optimize.Optimize(prog)
+
+	in.loading = append(in.loading, id)
+	defer func() { in.loading = in.loading[:len(in.loading)-1] }()
+	return prog, in.Eval(prog, env)
+}
+
+// Returns the values of the variables exported by the top level of prog.
+func exports(prog *ast.Program, env *obj.Env) map[string]obj.Object {
+	var ret = make(map[string]obj.Object)
+
+	for _, s := range prog.Statements {
+		if l, ok := s.(*ast.LetStatement); ok && l.Export {
+			ret[l.Name.Value], _ = env.Get(l.Name.Value)
+		}
+	}
+	return ret
+}
+
+func (in *Interpreter) evalImportStatement(node *ast.ImportStatement, env *obj.Env) obj.Object {
+	mod := in.importModule(node.Path)
+	if isError(mod) {
+		return locate(mod, node.Token.Pos)
+	}
+
+	if node.Name.Local {
+		env.SetAt(node.Name.Index, mod)
+	} else {
+		env.Set(node.Name.Value, mod)
+	}
+	return nil
+}
+
+// Returns the module at path, evaluating its file in a new environment the
+// first time it's imported.
+func (in *Interpreter) importModule(p string) obj.Object {
+	if !strings.HasPrefix(p, std) && in.profile.Caps&CapImport == 0 {
+		return newError("import: %s: not allowed by the %s profile", p, in.profile.Name)
+	}
+	id, err := in.resolveModule(p)
+	if err != nil {
+		return err
+	}
+	if mod, ok := in.modules[id]; ok {
+		return mod
+	}
+
+	for i, l := range in.loading {
+		if l == id {
+			var cycle []string
+			for _, l := range in.loading[i:] {
+				cycle = append(cycle, path.Base(l))
+			}
+			cycle = append(cycle, path.Base(id))
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	env := obj.NewEnv()
+	prog, ret := in.evalFile(id, env)
+	if prog == nil {
+		return newError("%s: %s", path.Base(id), ret.(*obj.Error).Msg)
+	}
+	if isError(ret) {
+		return ret
+	}
+
+	mod := &obj.Module{
+		Name:    strings.TrimSuffix(path.Base(id), ext),
+		Exports: exports(prog, env),
+	}
+	in.modules[id] = mod
+	return mod
+}
+
+// Returns the id of the module imported as p, as evalFile takes it. The
+// file is looked up in FS relative to the importing file first and then in
+// the search path, it can't be outside the root of FS.
+func (in *Interpreter) resolveModule(imp string) (string, obj.Object) {
+	var dirs = []string{"."}
+	var notFound = newError("import: module not found: %s", imp)
+
+	var p = imp
+	if path.Ext(p) == "" {
+		p += ext
+	}
+	if strings.HasPrefix(p, std) {
+		if _, err := fs.Stat(stdlib.FS, strings.TrimPrefix(p, std)); err != nil {
+			return "", notFound
+		}
+		return p, nil
+	}
+
+	if n := len(in.loading); n > 0 {
+		// The standard library can only import its own modules.
+		if strings.HasPrefix(in.loading[n-1], std) {
+			return "", notFound
+		}
+		dirs[0] = path.Dir(in.loading[n-1][1:])
+	}
+	if in.FS == nil {
+		return "", newError("import: no file system available")
+	}
+	if _, ok := fsPath(path.Join(dirs[0], p)); !ok || path.IsAbs(p) {
+		return "", newError("import: path outside the root: %s", imp)
+	}
+
+	for _, d := range append(dirs, in.SearchPath...) {
+		if name, ok := fsPath(path.Join(d, p)); ok && isFile(in.FS, name) {
+			return "/" + name, nil
+		}
+	}
+	return "", notFound
+}
+
+// Reports whether name is a regular file in fsys.
+func isFile(fsys fs.FS, name string) bool {
+	fi, err := fs.Stat(fsys, name)
+	return err == nil && !fi.IsDir()
+}
+
+// Returns the value exported by the module as the string index.
+func evalModuleIndexExpression(module, index obj.Object) obj.Object {
+	var m = module.(*obj.Module)
+	var name = index.(*obj.String).Value
+
+	if val, ok := m.Exports[name]; ok {
+		return val
+	}
+	return newError("%s: undefined export: %s", m.Name, name)
+}
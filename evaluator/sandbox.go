@@ -0,0 +1,243 @@
+package evaluator
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Capability is a set of permissions the builtins can require.
+type Capability uint
+
+const (
+	// CapIO allows using the standard input and output.
+	CapIO Capability = 1 << iota
+	// CapRead allows reading from the file system.
+	CapRead
+	// CapWrite allows writing to the file system.
+	CapWrite
+	// CapImport allows importing the files of the host, the standard
+	// library can always be imported.
+	CapImport
+	// CapTime allows reading the clock and waiting.
+	CapTime
+	// CapEnv allows reading the arguments and the environment variables
+	// the host passes to the scripts.
+	CapEnv
+	// CapExit allows ending the scripts with a status code.
+	CapExit
+
+	capAll = CapIO | CapRead | CapWrite | CapImport | CapTime | CapEnv | CapExit
+)
+
+// Capabilities required by the builtins, the ones not listed need none.
+var required = map[string]Capability{
+	"puts":    CapIO,
+	"print":   CapIO,
+	"println": CapIO,
+	"input":   CapIO,
+
+	"read_file":  CapRead,
+	"read_lines": CapRead,
+	"list_dir":   CapRead,
+	"exists":     CapRead,
+	"write_file": CapWrite,
+
+	"now":   CapTime,
+	"sleep": CapTime,
+
+	"args": CapEnv,
+	"env":  CapEnv,
+	"exit": CapExit,
+}
+
+// Profile is a named set of capabilities granted to the scripts.
+type Profile struct {
+	Name string
+	Caps Capability
+}
+
+var (
+	// Pure scripts can only compute values, they have no access to the
+	// host.
+	Pure = Profile{Name: "pure"}
+	// ReadOnly scripts can use the standard input and output, import
+	// files, read the file system, the clock, their arguments and
+	// environment, and exit.
+	ReadOnly = Profile{Name: "read-only", Caps: CapIO | CapRead | CapImport | CapTime | CapEnv | CapExit}
+	// Full scripts can do everything the builtins allow.
+	Full = Profile{Name: "full", Caps: capAll}
+)
+
+// LookupProfile returns the profile called name.
+func LookupProfile(name string) (Profile, bool) {
+	for _, p := range []Profile{Pure, ReadOnly, Full} {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// SetProfile makes visible to the scripts only the builtins whose required
+// capabilities are all granted by p. The others are not defined at all.
+func (in *Interpreter) SetProfile(p Profile) {
+	in.profile = p
+	in.builtins = make(map[string]*obj.Builtin, len(builtins)+len(boundBuiltins))
+
+	for name, b := range builtins {
+		if in.allowed(name) {
+			in.builtins[name] = b
+		}
+	}
+	for name, fn := range boundBuiltins {
+		if in.allowed(name) {
+			in.builtins[name] = in.bind(fn)
+		}
+	}
+}
+
+// Reports whether the profile of in grants the capabilities required by the
+// builtin name.
+func (in *Interpreter) allowed(name string) bool {
+	return required[name]&^in.profile.Caps == 0
+}
+
+// Limits bounds the resources the scripts can use, zero values mean no
+// limit.
+type Limits struct {
+	// MaxSteps is the number of nodes that can be evaluated. The elements
+	// visited by the operators and the builtins count as steps too.
+	MaxSteps int
+	// MaxDepth is the maximum depth of the nested function calls.
+	MaxDepth int
+	// MaxLen is the maximum length of the strings and arrays built by the
+	// operators and the builtins, and the maximum size in bytes of the
+	// integers.
+	MaxLen int
+	// Timeout is how long the evaluation can last.
+	Timeout time.Duration
+}
+
+// Resources used since the limits were set.
+type usage struct {
+	steps    int
+	depth    int
+	deadline time.Time
+	// Steps at which the clock is read next.
+	clock int
+}
+
+// SetLimits sets the resource limits of in and resets the resources used so
+// far, hosts call it before each evaluation the limits are meant for.
+func (in *Interpreter) SetLimits(l Limits) {
+	in.limits = l
+	in.limited = l != Limits{}
+	in.usage = usage{}
+	if l.Timeout > 0 {
+		in.usage.deadline = time.Now().Add(l.Timeout)
+	}
+}
+
+// Returns an error reporting a limit has been exceeded, the scripts can't
+// catch it.
+func limitError(format string, a ...interface{}) *obj.Error {
+	err := newError(format, a...)
+	err.Kind = "limit"
+	err.Fatal = true
+	return err
+}
+
+// Accounts for the evaluation of a node, failing if the steps or the time
+// are over.
+func (in *Interpreter) step() obj.Object {
+	return in.charge(1)
+}
+
+// Accounts for n steps of the work done natively by the operators and the
+// builtins, such as visiting the elements of arrays, failing like step.
+func (in *Interpreter) charge(n int) obj.Object {
+	if !in.limited {
+		return nil
+	}
+
+	in.usage.steps += n
+	if in.limits.MaxSteps > 0 && in.usage.steps > in.limits.MaxSteps {
+		return limitError("step limit exceeded: %d", in.limits.MaxSteps)
+	}
+	// Reading the clock is expensive, so it's done once every few steps.
+	if in.limits.Timeout > 0 && in.usage.steps >= in.usage.clock {
+		in.usage.clock = in.usage.steps + 1024
+		if time.Now().After(in.usage.deadline) {
+			return limitError("timeout exceeded: %s", in.limits.Timeout)
+		}
+	}
+	return nil
+}
+
+// Reports whether a and b are equal, accounting for the elements of the
+// arrays and the hashes compared.
+func (in *Interpreter) equal(a, b obj.Object) (bool, obj.Object) {
+	if !in.limited {
+		return a.Equal(b), nil
+	}
+
+	var err obj.Object
+	eq := obj.EqualFunc(a, b, func() bool {
+		err = in.charge(1)
+		return err == nil
+	})
+	return eq, err
+}
+
+// Returns the representation of o, accounting for the elements of the arrays
+// and the hashes shown.
+func (in *Interpreter) inspect(o obj.Object) (string, obj.Object) {
+	if !in.limited {
+		return o.Inspect(), nil
+	}
+
+	var err obj.Object
+	s, _ := obj.InspectFunc(o, func() bool {
+		err = in.charge(1)
+		return err == nil
+	})
+	return s, err
+}
+
+// Returns o or an error if it's larger than allowed.
+func (in *Interpreter) bounded(o obj.Object) obj.Object {
+	if in.limits.MaxLen <= 0 {
+		return o
+	}
+
+	var n int
+	switch o := o.(type) {
+	case *obj.String:
+		n = len(o.Value)
+	case *obj.Array:
+		n = len(o.Elements)
+	case *obj.BigInt:
+		n = (o.Value.BitLen() + 7) / 8
+	}
+	if n > in.limits.MaxLen {
+		return limitError("size limit exceeded: %d", in.limits.MaxLen)
+	}
+	return o
+}
+
+// Reports whether n pieces of size bytes exceed the size limit, without
+// computing their total which can overflow.
+func (in *Interpreter) tooLarge(n int64, size int) bool {
+	return in.limits.MaxLen > 0 && n > 0 && int64(size) > int64(in.limits.MaxLen)/n
+}
+
+// Reports whether raising base to exp yields an integer larger than the
+// size limit.
+func (in *Interpreter) powTooLarge(base *big.Int, exp int64) bool {
+	if in.limits.MaxLen <= 0 || base.BitLen() <= 1 {
+		return false
+	}
+	return exp > int64(in.limits.MaxLen)*8/int64(base.BitLen()-1)
+}
@@ -1,5 +1,5 @@
 module github.com/NicoNex/monkey
 
-go 1.15
+go 1.16
 
 require golang.org/x/crypto v0.0.0-20201117144127-c1f2f97bffc9
@@ -77,9 +77,9 @@ func (l *lexer) current() string {
 
 func (l *lexer) errorf(format string, args ...interface{}) {
 	l.tokens <- token.Token{
-		token.ILLEGAL,
-		fmt.Sprintf(format, args...),
-		l.start,
+		Typ: token.ILLEGAL,
+		Lit: fmt.Sprintf(format, args...),
+		Pos: l.start,
 	}
 	l.start = l.pos
 }
@@ -96,7 +96,12 @@ func lexOperator(l *lexer) stateFn {
 	case r == '+':
 		l.emit(token.PLUS)
 	case r == '-':
-		l.emit(token.MINUS)
+		if l.next() == '>' {
+			l.emit(token.ARROW)
+		} else {
+			l.backup()
+			l.emit(token.MINUS)
+		}
 	case r == '*':
 		if l.next() == '*' {
 			l.emit(token.POWER)
@@ -149,28 +154,91 @@ func lexIdentifier(l *lexer) stateFn {
 	return lexExpression
 }
 
+// Digits of the integer literals in each base.
+const (
+	binDigits = "01"
+	octDigits = "01234567"
+	decDigits = "0123456789"
+	hexDigits = "0123456789abcdefABCDEF"
+)
+
+// Lexes a number literal: an integer, decimal or prefixed by 0x, 0o or 0b
+// for hex, octal and binary, or a decimal float with a fractional part, an
+// exponent or both. Digits are optionally separated by single underscores.
 func lexNumber(l *lexer) stateFn {
-	var digits = "0123456789"
+	var digits = decDigits
+
+	if strings.HasPrefix(l.input[l.pos:], "0") && l.pos+1 < len(l.input) {
+		switch l.input[l.pos+1] {
+		case 'x', 'X':
+			digits = hexDigits
+		case 'o', 'O':
+			digits = octDigits
+		case 'b', 'B':
+			digits = binDigits
+		}
+		if digits != decDigits {
+			l.pos += 2
+		}
+	}
 
-	// Optional leading sign
-	l.accept("+-")
+	var typ = token.INT
 
-	// Is it hex?
-	if l.accept("0") && l.accept("xX") {
-		digits = "0123456789abcdefABCDEF"
+	if !l.acceptDigits(digits) {
+		return lexMalformedNumber
 	}
 
-	l.acceptRun(digits)
-	if l.accept(".") {
-		l.acceptRun(digits)
+	// A dot is part of the number only if a digit follows it, so that
+	// 1.name is still an index expression.
+	if digits == decDigits && l.peek() == '.' && l.digitAt(1) {
+		typ = token.FLOAT
+		l.next()
+		if !l.acceptDigits(digits) {
+			return lexMalformedNumber
+		}
 	}
-
-	if l.accept("eE") {
+	if r := l.peek(); digits == decDigits && (r == 'e' || r == 'E') {
+		typ = token.FLOAT
+		l.next()
 		l.accept("+-")
-		l.accept("0123456789")
+		if !l.acceptDigits(digits) {
+			return lexMalformedNumber
+		}
 	}
 
-	l.emit(token.INT)
+	if isLetter(l.peek()) || unicode.IsDigit(l.peek()) {
+		return lexMalformedNumber
+	}
+	l.emit(typ)
+	return lexExpression
+}
+
+// Consumes a run of digits from the valid set separated by single
+// underscores and reports whether it's well formed.
+func (l *lexer) acceptDigits(valid string) bool {
+	var n int
+
+	for l.accept(valid) {
+		n++
+		if l.accept("_") && strings.IndexRune(valid, l.peek()) < 0 {
+			return false
+		}
+	}
+	return n > 0
+}
+
+// Reports whether the byte at offset i from the current position is an
+// ASCII digit.
+func (l *lexer) digitAt(i int) bool {
+	return l.pos+i < len(l.input) && isNumber(rune(l.input[l.pos+i]))
+}
+
+// Skips the rest of a malformed number and reports it.
+func lexMalformedNumber(l *lexer) stateFn {
+	for r := l.peek(); isLetter(r) || unicode.IsDigit(r); r = l.peek() {
+		l.next()
+	}
+	l.errorf("lexer: malformed number %q", l.input[l.start:l.pos])
 	return lexExpression
 }
 
@@ -206,6 +274,12 @@ func lexExpression(l *lexer) stateFn {
 	case r == ';':
 		l.emit(token.SEMICOLON)
 
+	case r == ':':
+		l.emit(token.COLON)
+
+	case r == '.':
+		l.emit(token.DOT)
+
 	case r == '(':
 		l.emit(token.LPAREN)
 
@@ -255,7 +329,7 @@ func isOperator(r rune) bool {
 }
 
 func isNumber(r rune) bool {
-	return r == '+' || r == '-' || unicode.IsNumber(r)
+	return r >= '0' && r <= '9'
 }
 
 func Lex(in string) chan token.Token {
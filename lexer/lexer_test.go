@@ -29,6 +29,8 @@ if (5 < 10) {
 "foobar"
 "foo bar"
 [1, 2];
+fn(a: int) -> bool
+{"foo": "bar"}
 `
 
 	tests := []struct {
@@ -116,11 +118,19 @@ if (5 < 10) {
 		{token.INT, "2"},
 		{token.RBRACKET, "]"},
 		{token.SEMICOLON, ";"},
-		// {token.LBRACE, "{"},
-		// {token.STRING, "foo"},
-		// {token.COLON, ":"},
-		// {token.STRING, "bar"},
-		// {token.RBRACE, "}"},
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.RPAREN, ")"},
+		{token.ARROW, "->"},
+		{token.IDENT, "bool"},
+		{token.LBRACE, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.STRING, "bar"},
+		{token.RBRACE, "}"},
 		{token.EOF, ""},
 	}
 
@@ -142,3 +152,65 @@ if (5 < 10) {
 		i++
 	}
 }
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input  string
+		expTyp token.Type
+		expLit string
+	}{
+		{"0", token.INT, "0"},
+		{"1_000_000", token.INT, "1_000_000"},
+		{"0x1F", token.INT, "0x1F"},
+		{"0XdEaD_BeEf", token.INT, "0XdEaD_BeEf"},
+		{"0o755", token.INT, "0o755"},
+		{"0b1010_0101", token.INT, "0b1010_0101"},
+		{"007", token.INT, "007"},
+		{"0xZZ", token.ILLEGAL, `lexer: malformed number "0xZZ"`},
+		{"0x", token.ILLEGAL, `lexer: malformed number "0x"`},
+		{"1__0", token.ILLEGAL, `lexer: malformed number "1__0"`},
+		{"1_", token.ILLEGAL, `lexer: malformed number "1_"`},
+		{"0x_1", token.ILLEGAL, `lexer: malformed number "0x_1"`},
+		{"0o8", token.ILLEGAL, `lexer: malformed number "0o8"`},
+		{"0b102", token.ILLEGAL, `lexer: malformed number "0b102"`},
+		{"12abc", token.ILLEGAL, `lexer: malformed number "12abc"`},
+		{"1.5", token.FLOAT, "1.5"},
+		{"0.25", token.FLOAT, "0.25"},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1"},
+		{"1e10", token.FLOAT, "1e10"},
+		{"2.5E-3", token.FLOAT, "2.5E-3"},
+		{"1e+3", token.FLOAT, "1e+3"},
+		{"1e", token.ILLEGAL, `lexer: malformed number "1e"`},
+		{"1.5_", token.ILLEGAL, `lexer: malformed number "1.5_"`},
+		{"1.5x", token.ILLEGAL, `lexer: malformed number "1.5x"`},
+	}
+
+	for _, tt := range tests {
+		tok := <-Lex(tt.input)
+
+		if tok.Typ != tt.expTyp {
+			t.Errorf("%q - wrong token type: expected=%s, got=%s", tt.input, tt.expTyp, tok.Typ)
+		}
+		if tok.Lit != tt.expLit {
+			t.Errorf("%q - wrong token literal: expected=%q, got=%q", tt.input, tt.expLit, tok.Lit)
+		}
+	}
+
+	// Lexing goes on after a malformed number.
+	var typs []token.Type
+	for tok := range Lex("0xZZ + 1") {
+		typs = append(typs, tok.Typ)
+	}
+	if len(typs) != 4 || typs[1] != token.PLUS || typs[2] != token.INT {
+		t.Errorf("wrong tokens after a malformed number: %v", typs)
+	}
+
+	// A dot not followed by a digit is not part of the number.
+	typs = nil
+	for tok := range Lex("1.x") {
+		typs = append(typs, tok.Typ)
+	}
+	if len(typs) != 4 || typs[0] != token.INT || typs[1] != token.DOT || typs[2] != token.IDENT {
+		t.Errorf("wrong tokens for a dot after a number: %v", typs)
+	}
+}
@@ -0,0 +1,288 @@
+// Package lint statically analyses a Monkey program and reports likely
+// mistakes before it gets evaluated.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NicoNex/monkey/ast"
+	"github.com/NicoNex/monkey/evaluator"
+)
+
+// Issue is a single problem found in the program.
+type Issue struct {
+	Pos int
+	Msg string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d: %s", i.Pos, i.Msg)
+}
+
+type symbolKind int
+
+const (
+	letSym symbolKind = iota
+	paramSym
+	importSym
+)
+
+type symbol struct {
+	name string
+	pos  int
+	kind symbolKind
+	used bool
+	fn   *ast.FunctionLiteral
+}
+
+type scope struct {
+	outer   *scope
+	symbols map[string]*symbol
+	// All the symbols ever declared in the scope, rebound ones included.
+	decls []*symbol
+	// Function literals whose body is checked once the scope is complete.
+	deferred []*ast.FunctionLiteral
+}
+
+type linter struct {
+	issues []Issue
+}
+
+// Check returns the issues found in prog sorted by position.
+func Check(prog *ast.Program) []Issue {
+	var l linter
+
+	global := newScope(nil)
+	l.statements(prog.Statements, global)
+	l.close(global)
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Pos < l.issues[j].Pos
+	})
+	return l.issues
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, symbols: make(map[string]*symbol)}
+}
+
+// Returns the symbol bound to name in s or in any of its enclosing scopes.
+func (s *scope) lookup(name string) *symbol {
+	for ; s != nil; s = s.outer {
+		if sym, ok := s.symbols[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+func (l *linter) report(pos int, format string, a ...interface{}) {
+	l.issues = append(l.issues, Issue{Pos: pos, Msg: fmt.Sprintf(format, a...)})
+}
+
+func (l *linter) declare(id *ast.Identifier, kind symbolKind, s *scope) *symbol {
+	if evaluator.IsBuiltin(id.Value) {
+		l.report(id.Token.Pos, "%s shadows builtin function", id.Value)
+	}
+
+	sym := &symbol{name: id.Value, pos: id.Token.Pos, kind: kind}
+	s.symbols[id.Value] = sym
+	s.decls = append(s.decls, sym)
+	return sym
+}
+
+// Checks the bodies of the functions declared in s and reports its unused
+// symbols.
+func (l *linter) close(s *scope) {
+	// Function bodies can declare more functions so the list may grow.
+	for i := 0; i < len(s.deferred); i++ {
+		l.function(s.deferred[i], s)
+	}
+
+	for _, sym := range s.decls {
+		if sym.used || strings.HasPrefix(sym.name, "_") {
+			continue
+		}
+		switch sym.kind {
+		case paramSym:
+			l.report(sym.pos, "unused parameter: %s", sym.name)
+		case importSym:
+			l.report(sym.pos, "unused import: %s", sym.name)
+		default:
+			l.report(sym.pos, "unused variable: %s", sym.name)
+		}
+	}
+}
+
+func (l *linter) function(fn *ast.FunctionLiteral, outer *scope) {
+	s := newScope(outer)
+
+	for _, p := range fn.Params {
+		l.declare(p, paramSym, s)
+	}
+	l.statements(fn.Body.Statements, s)
+	l.close(s)
+}
+
+func (l *linter) statements(stmts []ast.Statement, s *scope) {
+	var unreachable bool
+
+	for _, stmt := range stmts {
+		if unreachable {
+			l.report(stmtPos(stmt), "unreachable code")
+			unreachable = false
+		}
+		l.statement(stmt, s)
+
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.ThrowStatement:
+			unreachable = true
+		}
+	}
+}
+
+func (l *linter) statement(stmt ast.Statement, s *scope) {
+	switch stmt := stmt.(type) {
+
+	case *ast.LetStatement:
+		l.expression(stmt.Value, s)
+		sym := l.declare(stmt.Name, letSym, s)
+		if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+			sym.fn = fn
+		}
+		// Exported variables are used by the importers.
+		sym.used = stmt.Export
+
+	case *ast.ImportStatement:
+		l.declare(stmt.Name, importSym, s)
+
+	case *ast.ReturnStatement:
+		l.expression(stmt.Value, s)
+
+	case *ast.ThrowStatement:
+		l.expression(stmt.Value, s)
+
+	case *ast.ExpressionStatement:
+		l.expression(stmt.Expr, s)
+
+	case *ast.BlockStatement:
+		l.statements(stmt.Statements, s)
+	}
+}
+
+func (l *linter) expression(expr ast.Expression, s *scope) {
+	switch expr := expr.(type) {
+
+	case *ast.Identifier:
+		l.identifier(expr, s)
+
+	case *ast.PrefixExpression:
+		l.expression(expr.Right, s)
+
+	case *ast.InfixExpression:
+		l.expression(expr.Left, s)
+		l.expression(expr.Right, s)
+
+	case *ast.IfExpression:
+		l.expression(expr.Condition, s)
+		l.statements(expr.Consequence.Statements, s)
+		if expr.Alternative != nil {
+			l.statements(expr.Alternative.Statements, s)
+		}
+
+	case *ast.TryExpression:
+		l.statements(expr.Body.Statements, s)
+		l.declare(expr.Param, paramSym, s)
+		l.statements(expr.Catch.Statements, s)
+
+	case *ast.FunctionLiteral:
+		// The body runs only when the function is called, so it can refer
+		// to anything declared later on in the enclosing scope.
+		s.deferred = append(s.deferred, expr)
+
+	case *ast.CallExpression:
+		l.expression(expr.Func, s)
+		for _, a := range expr.Args {
+			l.expression(a, s)
+		}
+		l.arity(expr, s)
+
+	case *ast.ArrayLiteral:
+		for _, e := range expr.Elements {
+			l.expression(e, s)
+		}
+
+	case *ast.IndexExpression:
+		l.expression(expr.Left, s)
+		l.expression(expr.Index, s)
+
+	case *ast.SliceExpression:
+		l.expression(expr.Left, s)
+		l.expression(expr.Start, s)
+		l.expression(expr.End, s)
+		l.expression(expr.Step, s)
+
+	case *ast.HashLiteral:
+		for _, k := range expr.Keys {
+			l.expression(k, s)
+			l.expression(expr.Pairs[k], s)
+		}
+	}
+}
+
+func (l *linter) identifier(id *ast.Identifier, s *scope) {
+	if sym := s.lookup(id.Value); sym != nil {
+		sym.used = true
+		return
+	}
+	if !evaluator.IsBuiltin(id.Value) {
+		l.report(id.Token.Pos, "undefined: %s", id.Value)
+	}
+}
+
+// Reports calls to known functions with the wrong number of arguments.
+func (l *linter) arity(call *ast.CallExpression, s *scope) {
+	var fn *ast.FunctionLiteral
+	var name = "fn"
+
+	switch f := call.Func.(type) {
+	case *ast.FunctionLiteral:
+		fn = f
+	case *ast.Identifier:
+		if sym := s.lookup(f.Value); sym != nil {
+			fn = sym.fn
+		}
+		name = f.Value
+	}
+
+	if fn != nil && len(fn.Params) != len(call.Args) {
+		l.report(
+			call.Token.Pos,
+			"%s: wrong number of arguments: got %d, want %d",
+			name,
+			len(call.Args),
+			len(fn.Params),
+		)
+	}
+}
+
+// Returns the position of the first token of stmt.
+func stmtPos(stmt ast.Statement) int {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token.Pos
+	case *ast.ReturnStatement:
+		return stmt.Token.Pos
+	case *ast.ThrowStatement:
+		return stmt.Token.Pos
+	case *ast.ImportStatement:
+		return stmt.Token.Pos
+	case *ast.ExpressionStatement:
+		return stmt.Token.Pos
+	case *ast.BlockStatement:
+		return stmt.Token.Pos
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/NicoNex/monkey/lexer"
+	"github.com/NicoNex/monkey/parser"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let a = 1; a;", nil},
+		{"lne([1]);", []string{"undefined: lne"}},
+		{"len([1]);", nil},
+		{"x; let x = 1; x;", []string{"undefined: x"}},
+		{"let x = 1;", []string{"unused variable: x"}},
+		{"let _x = 1;", nil},
+		{"let f = fn(a, b) { a }; f(1, 2);", []string{"unused parameter: b"}},
+		{"let len = fn(a) { a }; len(1);", []string{"len shadows builtin function"}},
+		{
+			"let f = fn(append) { append }; f(1);",
+			[]string{"append shadows builtin function"},
+		},
+		{
+			"let f = fn() { return 1; 2; 3; }; f();",
+			[]string{"unreachable code"},
+		},
+		{
+			"let f = fn() { throw \"no\"; 2; }; f();",
+			[]string{"unreachable code"},
+		},
+		{"try { 1 } catch (e) { e }", nil},
+		{"try { 1 } catch (e) { 2 }", []string{"unused parameter: e"}},
+		{"try { 1 } catch (_) { 2 }", nil},
+		{`import "lib/math"; math.pi;`, nil},
+		{`import "lib/math" as m;`, []string{"unused import: m"}},
+		{"export let x = 1;", nil},
+		{
+			"let f = fn(a) { a }; f(1, 2);",
+			[]string{"f: wrong number of arguments: got 2, want 1"},
+		},
+		{"fn(a) { a }();", []string{"fn: wrong number of arguments: got 0, want 1"}},
+		// Closures capture outer variables and parameters.
+		{"let add = fn(a) { fn(b) { a + b } }; add(1)(2);", nil},
+		// Function bodies can refer to bindings declared after them.
+		{"let f = fn() { g() }; let g = fn() { 1 }; f();", nil},
+		// Blocks don't introduce a new scope.
+		{"if (true) { let a = 1; } a;", nil},
+		{
+			"let f = fn() { let x = y; x }; f();",
+			[]string{"undefined: y"},
+		},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.Lex(tt.input))
+		program := p.Parse()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, errs)
+		}
+
+		issues := Check(program)
+		if len(issues) != len(tt.expected) {
+			t.Errorf("%q: wrong issues, got %v, want %q", tt.input, issues, tt.expected)
+			continue
+		}
+		for i, issue := range issues {
+			if issue.Msg != tt.expected[i] {
+				t.Errorf("%q: wrong issue, got %q, want %q", tt.input, issue.Msg, tt.expected[i])
+			}
+		}
+	}
+}
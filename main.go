@@ -3,10 +3,18 @@ package main
 import (
 	"fmt"
 	"github.com/NicoNex/monkey/repl"
+	"os"
 	"os/user"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "vet" {
+		os.Exit(vet(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(run(os.Args[2:]))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
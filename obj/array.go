@@ -1,10 +1,5 @@
 package obj
 
-import (
-	"fmt"
-	"strings"
-)
-
 type Array struct {
 	Elements []Object
 }
@@ -14,11 +9,10 @@ func (a *Array) Type() Type {
 }
 
 func (a *Array) Inspect() string {
-	var elements []string
-
-	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
-	}
+	s, _ := inspect(a, nil, nil)
+	return s
+}
 
-	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+func (a *Array) Equal(o Object) bool {
+	return equal(a, o, nil, nil)
 }
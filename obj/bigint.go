@@ -0,0 +1,32 @@
+package obj
+
+import "math/big"
+
+// BigInt is an integer that doesn't fit in an Integer. Integers are promoted
+// to BigInt when they overflow and demoted back when they fit again, so that
+// every value has a single representation.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+
+func (b *BigInt) Type() Type {
+	return BIGINT
+}
+
+func (b *BigInt) Equal(o Object) bool {
+	switch o := o.(type) {
+	case *BigInt:
+		return b.Value.Cmp(o.Value) == 0
+	case *Float:
+		return o.Equal(b)
+	}
+	return false
+}
+
+func (b *BigInt) HashKey() HashKey {
+	return HashKey{Type: BIGINT, Value: b.Value.String()}
+}
@@ -14,3 +14,10 @@ func (b *Boolean) Inspect() string {
 func (b *Boolean) Type() Type {
 	return BOOL
 }
+
+func (b *Boolean) Equal(o Object) bool {
+	if o, ok := o.(*Boolean); ok {
+		return b.Value == o.Value
+	}
+	return false
+}
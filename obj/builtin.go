@@ -13,3 +13,7 @@ func (b *Builtin) Type() Type {
 func (b *Builtin) Inspect() string {
 	return "builtin function"
 }
+
+func (b *Builtin) Equal(o Object) bool {
+	return b == o
+}
@@ -1,27 +1,49 @@
 package obj
 
+// Env holds the values bound to the variables of a scope.
+// Global variables are stored by name in the outermost environment, while
+// each function call gets an environment with a slot for each of its local
+// variables, addressed by the indices computed by the resolver.
 type Env struct {
-	store map[string]Object
-	outer *Env
+	store  map[string]Object
+	slots  []Object
+	outer  *Env
+	global *Env
 }
 
 func NewEnv() *Env {
-	return &Env{store: make(map[string]Object), outer: nil}
+	e := &Env{store: make(map[string]Object), outer: nil}
+	e.global = e
+	return e
 }
 
-func NewEnclosedEnv(outer *Env) *Env {
-	return &Env{store: make(map[string]Object), outer: outer}
+func NewEnclosedEnv(outer *Env, size int) *Env {
+	return &Env{slots: make([]Object, size), outer: outer, global: outer.global}
 }
 
+// Get returns the global variable called name.
 func (e *Env) Get(name string) (Object, bool) {
-	ret, ok := e.store[name]
-	if !ok && e.outer != nil {
-		ret, ok = e.outer.Get(name)
-	}
+	ret, ok := e.global.store[name]
 	return ret, ok
 }
 
+// Set binds val to the global variable called name.
 func (e *Env) Set(name string, val Object) Object {
-	e.store[name] = val
+	e.global.store[name] = val
+	return val
+}
+
+// GetAt returns the value in the slot index of the environment depth levels
+// above e, or nil if it's not been set yet.
+func (e *Env) GetAt(depth, index int) Object {
+	for ; depth > 0; depth-- {
+		e = e.outer
+	}
+	return e.slots[index]
+}
+
+// SetAt stores val in the slot index of e.
+func (e *Env) SetAt(index int, val Object) Object {
+	e.slots[index] = val
 	return val
 }
@@ -4,6 +4,18 @@ import "fmt"
 
 type Error struct {
 	Msg string
+	// Kind classifies the error, "runtime" for the errors raised by the
+	// interpreter itself.
+	Kind string
+	// Pos is the position in the source the error was raised at, or -1.
+	Pos int
+	// Caught errors are plain values: they have been either caught by a
+	// try expression or created by the script and not thrown yet. The
+	// other ones propagate up to the nearest try expression.
+	Caught bool
+	// Fatal errors end the evaluation, try expressions don't catch them.
+	// Only the interpreter raises them, for exceeded limits and exits.
+	Fatal bool
 }
 
 func (e *Error) Type() Type {
@@ -13,3 +25,10 @@ func (e *Error) Type() Type {
 func (e *Error) Inspect() string {
 	return fmt.Sprintf("error: %s", e.Msg)
 }
+
+func (e *Error) Equal(o Object) bool {
+	if o, ok := o.(*Error); ok {
+		return e.Msg == o.Msg && e.Kind == o.Kind
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package obj
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type Float struct {
+	Value float64
+}
+
+// Inspect formats f with the fewest digits that represent it exactly,
+// always showing that it is a float.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+func (f *Float) Type() Type {
+	return FLOAT
+}
+
+// Equal reports whether o is a number with the same value as f, integers
+// are converted to floats as the arithmetic operators do.
+func (f *Float) Equal(o Object) bool {
+	switch o := o.(type) {
+	case *Float:
+		return f.Value == o.Value
+	case *Integer:
+		return f.Value == float64(o.Value)
+	case *BigInt:
+		g, _ := new(big.Float).SetInt(o.Value).Float64()
+		return f.Value == g
+	}
+	return false
+}
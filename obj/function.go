@@ -10,6 +10,7 @@ type Function struct {
 	Params []*ast.Identifier
 	Body   *ast.BlockStatement
 	Env    *Env
+	Slots  int
 }
 
 func (f *Function) Type() Type {
@@ -24,3 +25,9 @@ func (f *Function) Inspect() string {
 	}
 	return fmt.Sprintf("fn(%s) {\n%s\n}", strings.Join(params, ", "), f.Body)
 }
+
+// Equal reports whether o is the same function, functions have no value to
+// compare.
+func (f *Function) Equal(o Object) bool {
+	return f == o
+}
@@ -0,0 +1,102 @@
+package obj
+
+import (
+	"math/big"
+	"sort"
+)
+
+// HashKey identifies the value of a hashable object, two objects with the
+// same type and value have the same key.
+type HashKey struct {
+	Type  Type
+	Value interface{}
+}
+
+// Hashable is implemented by the objects that can be used as hash keys.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+func (h *Hash) Type() Type {
+	return HASH
+}
+
+func (h *Hash) Inspect() string {
+	s, _ := inspect(h, nil, nil)
+	return s
+}
+
+func (h *Hash) Equal(o Object) bool {
+	return equal(h, o, nil, nil)
+}
+
+// Sorted returns the pairs of h ordered by type and then by key so that
+// iterating over a hash is deterministic.
+func (h *Hash) Sorted() []HashPair {
+	var pairs = make([]HashPair, 0, len(h.Pairs))
+
+	for _, p := range h.Pairs {
+		pairs = append(pairs, p)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		ki := pairs[i].Key.(Hashable).HashKey()
+		kj := pairs[j].Key.(Hashable).HashKey()
+
+		// Integers are ordered by value whatever their size.
+		if ti, tj := keyType(ki.Type), keyType(kj.Type); ti != tj {
+			return ti < tj
+		}
+		if ki.Type == BIGINT || kj.Type == BIGINT {
+			return bigKey(pairs[i].Key).Cmp(bigKey(pairs[j].Key)) < 0
+		}
+		switch vi := ki.Value.(type) {
+		case int64:
+			return vi < kj.Value.(int64)
+		case string:
+			return vi < kj.Value.(string)
+		case bool:
+			return !vi && kj.Value.(bool)
+		}
+		return false
+	})
+	return pairs
+}
+
+// Returns the type t sorts as in Sorted, where big integers are integers.
+func keyType(t Type) Type {
+	if t == BIGINT {
+		return INT
+	}
+	return t
+}
+
+// Returns the value of the integer key k, of any size.
+func bigKey(k Object) *big.Int {
+	if b, ok := k.(*BigInt); ok {
+		return b.Value
+	}
+	return big.NewInt(k.(*Integer).Value)
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: INT, Value: i.Value}
+}
+
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: STRING, Value: s.Value}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	return HashKey{Type: BOOL, Value: b.Value}
+}
@@ -14,3 +14,13 @@ func (i *Integer) Inspect() string {
 func (i *Integer) Type() Type {
 	return INT
 }
+
+func (i *Integer) Equal(o Object) bool {
+	switch o := o.(type) {
+	case *Integer:
+		return i.Value == o.Value
+	case *Float:
+		return o.Equal(i)
+	}
+	return false
+}
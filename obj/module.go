@@ -0,0 +1,24 @@
+package obj
+
+import "fmt"
+
+// Module is the value an import statement binds, it holds the variables the
+// imported file exports.
+type Module struct {
+	Name    string
+	Exports map[string]Object
+}
+
+func (m *Module) Type() Type {
+	return MODULE
+}
+
+func (m *Module) Inspect() string {
+	return fmt.Sprintf("<module %s>", m.Name)
+}
+
+// Equal reports whether o is the same module, each file is loaded once so
+// two imports of the same file yield the same module.
+func (m *Module) Equal(o Object) bool {
+	return m == o
+}
@@ -9,3 +9,8 @@ func (n *Null) Inspect() string {
 func (n *Null) Type() Type {
 	return NULL
 }
+
+func (n *Null) Equal(o Object) bool {
+	_, ok := o.(*Null)
+	return ok
+}
@@ -1,8 +1,17 @@
 package obj
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Object interface {
 	Type() Type
 	Inspect() string
+	// Equal reports whether the object has the same type and value as o,
+	// composite objects are compared element by element. Numbers are
+	// compared by value whatever their type.
+	Equal(o Object) bool
 }
 
 type Type int
@@ -17,6 +26,10 @@ const (
 	FUNCTION
 	BUILTIN
 	ARRAY
+	HASH
+	BIGINT
+	MODULE
+	FLOAT
 )
 
 var typrepr = map[Type]string{
@@ -29,8 +42,144 @@ var typrepr = map[Type]string{
 	FUNCTION: "FUNCTION",
 	BUILTIN:  "BUILTIN",
 	ARRAY:    "ARRAY",
+	HASH:     "HASH",
+	// Big integers are just integers to the user.
+	BIGINT: "INTEGER",
+	MODULE: "MODULE",
+	FLOAT:  "FLOAT",
 }
 
 func (t Type) String() string {
 	return typrepr[t]
 }
+
+// EqualFunc reports whether a and b are equal like a.Equal(b), calling visit
+// before comparing each pair of elements of the arrays and the hashes. The
+// comparison stops, reporting them different, if visit returns false.
+func EqualFunc(a, b Object, visit func() bool) bool {
+	return equal(a, b, nil, visit)
+}
+
+// Pair of composite objects being compared.
+type pair struct {
+	a, b Object
+}
+
+// Reports whether a and b are equal, comparing the arrays and the hashes
+// element by element and calling visit, if not nil, as in EqualFunc. The
+// pairs of composite objects already compared are in seen: a pair found
+// again is either being compared, since it contains itself, or equal,
+// otherwise the comparison would have stopped. Either way it's equal, which
+// also keeps the shared elements from being compared more than once.
+func equal(a, b Object, seen map[pair]bool, visit func() bool) bool {
+	switch a := a.(type) {
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		if a == b || seen[pair{a, b}] {
+			return true
+		}
+		if seen == nil {
+			seen = make(map[pair]bool)
+		}
+		seen[pair{a, b}] = true
+
+		for i, e := range a.Elements {
+			if visit != nil && !visit() {
+				return false
+			}
+			if !equal(e, b.Elements[i], seen, visit) {
+				return false
+			}
+		}
+		return true
+
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		if a == b || seen[pair{a, b}] {
+			return true
+		}
+		if seen == nil {
+			seen = make(map[pair]bool)
+		}
+		seen[pair{a, b}] = true
+
+		for k, p := range a.Pairs {
+			if visit != nil && !visit() {
+				return false
+			}
+			q, ok := b.Pairs[k]
+			if !ok || !equal(p.Value, q.Value, seen, visit) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return a.Equal(b)
+	}
+}
+
+// InspectFunc returns the representation of o like o.Inspect(), calling
+// visit before showing each element of the arrays and the hashes. It stops,
+// returning false, if visit returns false.
+func InspectFunc(o Object, visit func() bool) (string, bool) {
+	return inspect(o, nil, visit)
+}
+
+// Returns the representation of o like Inspect, calling visit, if not nil,
+// as in InspectFunc. The arrays and the hashes being inspected are in path,
+// those found again contain themselves and are shown as [...] and {...}.
+func inspect(o Object, path []Object, visit func() bool) (string, bool) {
+	switch o := o.(type) {
+	case *Array:
+		for _, p := range path {
+			if p == o {
+				return "[...]", true
+			}
+		}
+		path = append(path, o)
+
+		var elements []string
+		for _, e := range o.Elements {
+			if visit != nil && !visit() {
+				return "", false
+			}
+			s, ok := inspect(e, path, visit)
+			if !ok {
+				return "", false
+			}
+			elements = append(elements, s)
+		}
+		return fmt.Sprintf("[%s]", strings.Join(elements, ", ")), true
+
+	case *Hash:
+		for _, p := range path {
+			if p == o {
+				return "{...}", true
+			}
+		}
+		path = append(path, o)
+
+		var pairs []string
+		for _, p := range o.Sorted() {
+			if visit != nil && !visit() {
+				return "", false
+			}
+			s, ok := inspect(p.Value, path, visit)
+			if !ok {
+				return "", false
+			}
+			pairs = append(pairs, fmt.Sprintf("%s: %s", p.Key.Inspect(), s))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(pairs, ", ")), true
+
+	default:
+		return o.Inspect(), true
+	}
+}
@@ -11,3 +11,10 @@ func (r *ReturnValue) Type() Type {
 func (r *ReturnValue) Inspect() string {
 	return r.Value.Inspect()
 }
+
+func (r *ReturnValue) Equal(o Object) bool {
+	if o, ok := o.(*ReturnValue); ok {
+		return r.Value.Equal(o.Value)
+	}
+	return false
+}
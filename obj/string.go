@@ -11,3 +11,10 @@ func (s *String) Type() Type {
 func (s *String) Inspect() string {
 	return s.Value
 }
+
+func (s *String) Equal(o Object) bool {
+	if o, ok := o.(*String); ok {
+		return s.Value == o.Value
+	}
+	return false
+}
@@ -0,0 +1,474 @@
+// Package optimize rewrites a Monkey program into an equivalent one that is
+// cheaper to evaluate.
+//
+// It folds the infix and prefix expressions whose operands are literals,
+// removes the branches of if expressions whose condition is a literal and
+// inlines the let bindings of functions whose value is a literal.
+// Expressions that fail at runtime, like a division by zero, are left as they
+// are so that they keep failing the same way.
+//
+// Top level bindings are never inlined since they can be rebound by later
+// evaluations in the same environment, as it happens in the REPL.
+package optimize
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/NicoNex/monkey/ast"
+	"github.com/NicoNex/monkey/token"
+)
+
+// Holds the constants that can be inlined in a function body.
+type scope struct {
+	consts map[string]ast.Expression
+	// Number of times each name is bound in the function.
+	bound map[string]int
+}
+
+// Optimize rewrites prog in place and returns it.
+func Optimize(prog *ast.Program) *ast.Program {
+	prog.Statements = statements(prog.Statements, nil, false)
+	return prog
+}
+
+// Optimizes stmts, top is true if they're the body of the function of sc.
+func statements(stmts []ast.Statement, sc *scope, top bool) []ast.Statement {
+	var ret []ast.Statement
+
+	for i, s := range stmts {
+		s = statement(s, sc, top)
+
+		// Replace the if expressions with a literal condition with the
+		// statements of the branch that is taken.
+		if es, ok := s.(*ast.ExpressionStatement); ok {
+			if ie, ok := es.Expr.(*ast.IfExpression); ok {
+				if cond, ok := constCondition(ie); ok {
+					block := ie.Alternative
+					if cond {
+						block = ie.Consequence
+					}
+
+					last := i == len(stmts)-1
+					if !last || (block != nil && len(block.Statements) > 0) {
+						if block != nil {
+							ret = append(ret, block.Statements...)
+						}
+						continue
+					}
+				}
+			}
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+func statement(s ast.Statement, sc *scope, top bool) ast.Statement {
+	switch s := s.(type) {
+
+	case *ast.LetStatement:
+		s.Value = expression(s.Value, sc)
+		if sc != nil {
+			if top && isLiteral(s.Value) && sc.bound[s.Name.Value] == 1 {
+				sc.consts[s.Name.Value] = s.Value
+			} else {
+				delete(sc.consts, s.Name.Value)
+			}
+		}
+
+	case *ast.ImportStatement:
+		if sc != nil {
+			delete(sc.consts, s.Name.Value)
+		}
+
+	case *ast.ReturnStatement:
+		s.Value = expression(s.Value, sc)
+
+	case *ast.ThrowStatement:
+		s.Value = expression(s.Value, sc)
+
+	case *ast.ExpressionStatement:
+		s.Expr = expression(s.Expr, sc)
+
+	case *ast.BlockStatement:
+		block(s, sc)
+	}
+	return s
+}
+
+func block(b *ast.BlockStatement, sc *scope) {
+	if b != nil {
+		b.Statements = statements(b.Statements, sc, false)
+	}
+}
+
+func expression(e ast.Expression, sc *scope) ast.Expression {
+	switch e := e.(type) {
+
+	case *ast.Identifier:
+		if sc != nil {
+			if c, ok := sc.consts[e.Value]; ok {
+				return clone(c)
+			}
+		}
+
+	case *ast.PrefixExpression:
+		e.Right = expression(e.Right, sc)
+		if f := foldPrefix(e); f != nil {
+			return f
+		}
+
+	case *ast.InfixExpression:
+		e.Left = expression(e.Left, sc)
+		e.Right = expression(e.Right, sc)
+		if f := foldInfix(e); f != nil {
+			return f
+		}
+
+	case *ast.IfExpression:
+		return ifExpression(e, sc)
+
+	case *ast.TryExpression:
+		block(e.Body, sc)
+		if sc != nil {
+			delete(sc.consts, e.Param.Value)
+		}
+		block(e.Catch, sc)
+
+	case *ast.FunctionLiteral:
+		function(e, sc)
+
+	case *ast.CallExpression:
+		e.Func = expression(e.Func, sc)
+		for i, a := range e.Args {
+			e.Args[i] = expression(a, sc)
+		}
+
+	case *ast.ArrayLiteral:
+		for i, el := range e.Elements {
+			e.Elements[i] = expression(el, sc)
+		}
+
+	case *ast.IndexExpression:
+		e.Left = expression(e.Left, sc)
+		e.Index = expression(e.Index, sc)
+
+	case *ast.SliceExpression:
+		e.Left = expression(e.Left, sc)
+		e.Start = expression(e.Start, sc)
+		e.End = expression(e.End, sc)
+		e.Step = expression(e.Step, sc)
+
+	case *ast.HashLiteral:
+		// The keys are rebuilt since folding them changes their identity.
+		var pairs = make(map[ast.Expression]ast.Expression, len(e.Keys))
+
+		for i, k := range e.Keys {
+			v := expression(e.Pairs[k], sc)
+			e.Keys[i] = expression(k, sc)
+			pairs[e.Keys[i]] = v
+		}
+		e.Pairs = pairs
+	}
+	return e
+}
+
+func ifExpression(ie *ast.IfExpression, sc *scope) ast.Expression {
+	ie.Condition = expression(ie.Condition, sc)
+	block(ie.Consequence, sc)
+	block(ie.Alternative, sc)
+
+	cond, ok := constCondition(ie)
+	if !ok {
+		return ie
+	}
+
+	if !cond {
+		if ie.Alternative == nil {
+			return ie
+		}
+		ie.Consequence = ie.Alternative
+	}
+	ie.Alternative = nil
+	ie.Condition = newBoolean(ie.Condition, true)
+
+	// A branch made of a single expression is just that expression.
+	if stmts := ie.Consequence.Statements; len(stmts) == 1 {
+		if es, ok := stmts[0].(*ast.ExpressionStatement); ok && es.Expr != nil {
+			return es.Expr
+		}
+	}
+	return ie
+}
+
+func function(fn *ast.FunctionLiteral, outer *scope) {
+	var sc = &scope{
+		consts: make(map[string]ast.Expression),
+		bound:  make(map[string]int),
+	}
+
+	for _, p := range fn.Params {
+		sc.bound[p.Value]++
+	}
+	countLets(fn.Body, sc.bound)
+
+	if outer != nil {
+		for name, c := range outer.consts {
+			if sc.bound[name] == 0 {
+				sc.consts[name] = c
+			}
+		}
+	}
+	fn.Body.Statements = statements(fn.Body.Statements, sc, true)
+}
+
+// Counts the let statements in b, ignoring the ones in nested functions
+// since they belong to a different scope.
+func countLets(b *ast.BlockStatement, bound map[string]int) {
+	if b == nil {
+		return
+	}
+
+	for _, s := range b.Statements {
+		switch s := s.(type) {
+		case *ast.LetStatement:
+			bound[s.Name.Value]++
+			countExprLets(s.Value, bound)
+		case *ast.ImportStatement:
+			bound[s.Name.Value]++
+		case *ast.ReturnStatement:
+			countExprLets(s.Value, bound)
+		case *ast.ThrowStatement:
+			countExprLets(s.Value, bound)
+		case *ast.ExpressionStatement:
+			countExprLets(s.Expr, bound)
+		case *ast.BlockStatement:
+			countLets(s, bound)
+		}
+	}
+}
+
+func countExprLets(e ast.Expression, bound map[string]int) {
+	switch e := e.(type) {
+	case *ast.PrefixExpression:
+		countExprLets(e.Right, bound)
+	case *ast.InfixExpression:
+		countExprLets(e.Left, bound)
+		countExprLets(e.Right, bound)
+	case *ast.IfExpression:
+		countExprLets(e.Condition, bound)
+		countLets(e.Consequence, bound)
+		countLets(e.Alternative, bound)
+	case *ast.TryExpression:
+		countLets(e.Body, bound)
+		// The caught error is bound like a let statement would bind it.
+		bound[e.Param.Value]++
+		countLets(e.Catch, bound)
+	case *ast.CallExpression:
+		countExprLets(e.Func, bound)
+		for _, a := range e.Args {
+			countExprLets(a, bound)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range e.Elements {
+			countExprLets(el, bound)
+		}
+	case *ast.IndexExpression:
+		countExprLets(e.Left, bound)
+		countExprLets(e.Index, bound)
+	case *ast.SliceExpression:
+		countExprLets(e.Left, bound)
+		countExprLets(e.Start, bound)
+		countExprLets(e.End, bound)
+		countExprLets(e.Step, bound)
+	case *ast.HashLiteral:
+		for _, k := range e.Keys {
+			countExprLets(k, bound)
+			countExprLets(e.Pairs[k], bound)
+		}
+	}
+}
+
+// Returns the truthiness of the condition of ie and whether it's a literal.
+func constCondition(ie *ast.IfExpression) (bool, bool) {
+	switch c := ie.Condition.(type) {
+	case *ast.Boolean:
+		return c.Value, true
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+		return true, true
+	}
+	return false, false
+}
+
+// Returns a copy of the literal e, each inlined use of a constant gets its own
+// node since hash literals tell their keys apart by identity.
+func clone(e ast.Expression) ast.Expression {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		c := *e
+		return &c
+	case *ast.FloatLiteral:
+		c := *e
+		return &c
+	case *ast.StringLiteral:
+		c := *e
+		return &c
+	case *ast.Boolean:
+		c := *e
+		return &c
+	}
+	return e
+}
+
+func isLiteral(e ast.Expression) bool {
+	switch e.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	}
+	return false
+}
+
+func foldPrefix(p *ast.PrefixExpression) ast.Expression {
+	switch p.Operator {
+	case "!":
+		switch r := p.Right.(type) {
+		case *ast.Boolean:
+			return newBoolean(p, !r.Value)
+		case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+			return newBoolean(p, false)
+		}
+
+	case "-":
+		if r, ok := p.Right.(*ast.IntegerLiteral); ok && r.Big == nil && r.Value != math.MinInt64 {
+			return newInteger(p, -r.Value)
+		}
+	}
+	return nil
+}
+
+func foldInfix(i *ast.InfixExpression) ast.Expression {
+	switch l := i.Left.(type) {
+	case *ast.IntegerLiteral:
+		if r, ok := i.Right.(*ast.IntegerLiteral); ok && l.Big == nil && r.Big == nil {
+			return foldIntInfix(i, l.Value, r.Value)
+		}
+
+	case *ast.StringLiteral:
+		if r, ok := i.Right.(*ast.StringLiteral); ok {
+			return foldStrInfix(i, l.Value, r.Value)
+		}
+
+	case *ast.Boolean:
+		if r, ok := i.Right.(*ast.Boolean); ok {
+			switch i.Operator {
+			case "==":
+				return newBoolean(i, l.Value == r.Value)
+			case "!=":
+				return newBoolean(i, l.Value != r.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// Folds the arithmetic on int64 operands, the operations that overflow are
+// left to the evaluator since it promotes them to big integers.
+func foldIntInfix(i *ast.InfixExpression, l, r int64) ast.Expression {
+	var x, y = big.NewInt(l), big.NewInt(r)
+
+	switch i.Operator {
+	case "+":
+		return newBigInteger(i, x.Add(x, y))
+	case "-":
+		return newBigInteger(i, x.Sub(x, y))
+	case "*":
+		return newBigInteger(i, x.Mul(x, y))
+	case "/":
+		if r != 0 {
+			return newBigInteger(i, x.Quo(x, y))
+		}
+	case "==":
+		return newBoolean(i, l == r)
+	case "!=":
+		return newBoolean(i, l != r)
+	case "<":
+		return newBoolean(i, l < r)
+	case ">":
+		return newBoolean(i, l > r)
+	case "<=":
+		return newBoolean(i, l <= r)
+	case ">=":
+		return newBoolean(i, l >= r)
+	}
+	return nil
+}
+
+func foldStrInfix(i *ast.InfixExpression, l, r string) ast.Expression {
+	switch i.Operator {
+	case "+":
+		return newString(i, l+r)
+	case "==":
+		return newBoolean(i, l == r)
+	case "!=":
+		return newBoolean(i, l != r)
+	case "<":
+		return newBoolean(i, l < r)
+	case ">":
+		return newBoolean(i, l > r)
+	case "<=":
+		return newBoolean(i, l <= r)
+	case ">=":
+		return newBoolean(i, l >= r)
+	}
+	return nil
+}
+
+// Returns the position of the expression e replaces.
+func pos(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.PrefixExpression:
+		return e.Token.Pos
+	case *ast.InfixExpression:
+		return e.Token.Pos
+	case *ast.Boolean:
+		return e.Token.Pos
+	case *ast.IntegerLiteral:
+		return e.Token.Pos
+	case *ast.StringLiteral:
+		return e.Token.Pos
+	}
+	return 0
+}
+
+func newInteger(orig ast.Expression, v int64) *ast.IntegerLiteral {
+	return &ast.IntegerLiteral{
+		Token: token.Token{Typ: token.INT, Lit: strconv.FormatInt(v, 10), Pos: pos(orig)},
+		Value: v,
+	}
+}
+
+// Returns the literal for v if it fits in an int64, nil otherwise.
+func newBigInteger(orig ast.Expression, v *big.Int) ast.Expression {
+	if !v.IsInt64() {
+		return nil
+	}
+	return newInteger(orig, v.Int64())
+}
+
+func newString(orig ast.Expression, v string) *ast.StringLiteral {
+	return &ast.StringLiteral{
+		Token: token.Token{Typ: token.STRING, Lit: v, Pos: pos(orig)},
+		Value: v,
+	}
+}
+
+func newBoolean(orig ast.Expression, v bool) *ast.Boolean {
+	var tok = token.Token{Typ: token.FALSE, Lit: "false", Pos: pos(orig)}
+
+	if v {
+		tok.Typ, tok.Lit = token.TRUE, "true"
+	}
+	return &ast.Boolean{Token: tok, Value: v}
+}
@@ -0,0 +1,71 @@
+package optimize
+
+import (
+	"testing"
+
+	"github.com/NicoNex/monkey/lexer"
+	"github.com/NicoNex/monkey/parser"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24", "86400"},
+		{"1 + 2 * 3 - 4 / 2", "5"},
+		{"-(2 + 3)", "-5"},
+		{"1 < 2", "true"},
+		{"1 >= 2 == false", "true"},
+		{`"a" + "b" + "c"`, "abc"},
+		{`"a" == "b"`, "false"},
+		{`"a" < "b"`, "true"},
+		// Overflows are promoted to big integers by the evaluator.
+		{"9223372036854775807 + 1", "(9223372036854775807 + 1)"},
+		{"-9223372036854775807 - 1", "-9223372036854775808"},
+		{"-(-9223372036854775807 - 1)", "(--9223372036854775808)"},
+		{"99999999999999999999 - 1", "(99999999999999999999 - 1)"},
+		{`"b" <= "a"`, "false"},
+		{"!true", "false"},
+		{"!5", "false"},
+		{"true != false", "true"},
+		{"x * (2 + 3)", "(x * 5)"},
+		// Errors are left for the evaluator.
+		{"1 / 0", "(1 / 0)"},
+		{"5 + true", "(5 + true)"},
+		{"true + false", "(true + false)"},
+		{`"a" - "b"`, "(a - b)"},
+		{"-true", "(-true)"},
+		// Dead branches.
+		{"if (true) { x } else { y }", "x"},
+		{"if (false) { x } else { y }", "y"},
+		{"if (1 > 2) { x }", "if false x"},
+		{"if (1 > 2) { x }; y", "y"},
+		{"if (1 < 2) { let a = 1; a }; y", "let a = 1;ay"},
+		{"let v = if (true) { 1 + 1 } else { 3 };", "let v = 2;"},
+		// Function constants are inlined, top level ones aren't.
+		{"let a = 2; a * 3", "let a = 2;(a * 3)"},
+		{"fn() { let a = 60; a * 60 }", "fn() let a = 60;3600"},
+		{"fn() { let a = 1; fn(b) { a + b } }", "fn() let a = 1;fn(b) (1 + b)"},
+		{"fn() { let a = 1; fn(a) { a } }", "fn() let a = 1;fn(a) a"},
+		{"fn() { let a = 1; let a = 2; a }", "fn() let a = 1;let a = 2;a"},
+		{"fn() { a; let a = 1; a }", "fn() alet a = 1;1"},
+		{"fn(a) { let b = a; b }", "fn(a) let b = a;b"},
+		// Floats are inlined but not folded.
+		{"fn() { let a = 1.5; a * 2 }", "fn() let a = 1.5;(1.5 * 2)"},
+		{"!1.5", "false"},
+		{"fn(c) { if (c) { let a = 1; }; a }", "fn(c) if c let a = 1;a"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.Lex(tt.input))
+		program := p.Parse()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, errs)
+		}
+
+		if s := Optimize(program).String(); s != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, s)
+		}
+	}
+}
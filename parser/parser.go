@@ -3,8 +3,13 @@ package parser
 import (
 	"fmt"
 	"github.com/NicoNex/monkey/ast"
+	"github.com/NicoNex/monkey/resolver"
 	"github.com/NicoNex/monkey/token"
+	"math/big"
+	"path"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
 type Parser struct {
@@ -14,6 +19,8 @@ type Parser struct {
 	errors        []string
 	prefixParsers map[token.Type]parsePrefixFn
 	infixParsers  map[token.Type]parseInfixFn
+	// Number of blocks the current token is nested in.
+	depth int
 }
 
 type (
@@ -48,6 +55,7 @@ var precedences = map[token.Type]int{
 	token.POWER:    PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
 }
 
 func New(tokens chan token.Token) *Parser {
@@ -60,6 +68,7 @@ func New(tokens chan token.Token) *Parser {
 	}
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
@@ -67,9 +76,12 @@ func New(tokens chan token.Token) *Parser {
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
+	p.registerInfix(token.DOT, p.parseDotExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
@@ -105,6 +117,9 @@ func (p *Parser) Parse() *ast.Program {
 		p.next()
 	}
 
+	if len(p.errors) == 0 {
+		resolver.Resolve(prog)
+	}
 	return prog
 }
 
@@ -114,6 +129,12 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -126,7 +147,14 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	s.Name = &ast.Identifier{p.cur, p.cur.Lit}
+	s.Name = &ast.Identifier{Token: p.cur, Value: p.cur.Lit}
+
+	if p.peek.Is(token.COLON) {
+		p.next()
+		if s.Name.Type = p.parseTypeAnnotation(); s.Name.Type == nil {
+			return nil
+		}
+	}
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -151,6 +179,82 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return r
 }
 
+func (p *Parser) parseExportStatement() *ast.LetStatement {
+	if p.depth > 0 {
+		p.errors = append(p.errors, "export is only allowed at the top level")
+		return nil
+	}
+	if !p.expectPeek(token.LET) {
+		return nil
+	}
+
+	s := p.parseLetStatement()
+	if s != nil {
+		s.Export = true
+	}
+	return s
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	var s = &ast.ImportStatement{Token: p.cur}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	s.Path = p.cur.Lit
+
+	if p.peek.Is(token.IDENT) && p.peek.Lit == "as" {
+		p.next()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		s.Name = &ast.Identifier{Token: p.cur, Value: p.cur.Lit}
+	} else {
+		name := moduleName(s.Path)
+		if name == "" {
+			msg := fmt.Sprintf("cannot import %q without a name, use import %q as name", s.Path, s.Path)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		tok := token.Token{Typ: token.IDENT, Lit: name, Pos: s.Token.Pos}
+		s.Name = &ast.Identifier{Token: tok, Value: name}
+	}
+
+	if p.peek.Is(token.SEMICOLON) {
+		p.next()
+	}
+	return s
+}
+
+// Returns the name a module imported from path is bound to by default, the
+// base name of the file without extension, or an empty string if that is
+// not a valid identifier.
+func moduleName(p string) string {
+	var name = path.Base(p)
+
+	name = strings.TrimSuffix(name, path.Ext(name))
+	for i, r := range name {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return ""
+		}
+	}
+	if name == "" || token.LookupIdent(name) != token.IDENT {
+		return ""
+	}
+	return name
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	var t = &ast.ThrowStatement{Token: p.cur}
+
+	p.next()
+	t.Value = p.parseExpression(LOWEST)
+	if p.peek.Is(token.SEMICOLON) {
+		p.next()
+	}
+	return t
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	var ret = &ast.ExpressionStatement{
 		Token: p.cur,
@@ -182,6 +286,11 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		}
 		return leftExp
 	}
+	if p.cur.Is(token.ILLEGAL) {
+		// The lexer reports its errors in the literal of illegal tokens.
+		p.errors = append(p.errors, p.cur.Lit)
+		return nil
+	}
 	p.noParsePrefixFnError(p.cur.Typ)
 	return nil
 }
@@ -218,14 +327,45 @@ func (p *Parser) parseIdentifier() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	var r = &ast.IntegerLiteral{Token: p.cur}
 
-	i, err := strconv.ParseInt(p.cur.Lit, 0, 64)
+	var lit, base = strings.ReplaceAll(p.cur.Lit, "_", ""), 10
+
+	if len(lit) > 2 && lit[0] == '0' {
+		switch lit[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			lit = lit[2:]
+		}
+	}
+
+	i, err := strconv.ParseInt(lit, base, 64)
+	if err == nil {
+		r.Value = i
+		return r
+	}
+
+	if b, ok := new(big.Int).SetString(lit, base); ok {
+		r.Big = b
+		return r
+	}
+	msg := fmt.Sprintf("could not parse %q as an integer", p.cur.Lit)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(p.cur.Lit, "_", ""), 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as an integer", p.cur.Lit)
+		msg := fmt.Sprintf("could not parse %q as a float", p.cur.Lit)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
-	r.Value = i
-	return r
+	return &ast.FloatLiteral{Token: p.cur, Value: f}
 }
 
 // Returns a string expression
@@ -280,6 +420,28 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expr
 }
 
+// Parses try { ... } catch (e) { ... }.
+func (p *Parser) parseTryExpression() ast.Expression {
+	var expr = &ast.TryExpression{Token: p.cur}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expr.Body = p.parseBlockStatement()
+
+	if !p.expectPeek(token.CATCH) || !p.expectPeek(token.LPAREN) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Param = &ast.Identifier{Token: p.cur, Value: p.cur.Lit}
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expr.Catch = p.parseBlockStatement()
+
+	return expr
+}
+
 // Returns the expression representing the function.
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	var expr = &ast.FunctionLiteral{Token: p.cur}
@@ -290,6 +452,13 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 
 	expr.Params = p.parseFunctionParams()
 
+	if p.peek.Is(token.ARROW) {
+		p.next()
+		if expr.Ret = p.parseTypeAnnotation(); expr.Ret == nil {
+			return nil
+		}
+	}
+
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
@@ -299,10 +468,37 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 }
 
 func (p *Parser) parseArrayLiteral() ast.Expression {
-	return &ast.ArrayLiteral{
-		Token:    p.cur,
-		Elements: p.parseExpressionList(token.RBRACKET),
+	var array = &ast.ArrayLiteral{Token: p.cur}
+
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	return array
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	var hash = &ast.HashLiteral{Token: p.cur, Pairs: make(map[ast.Expression]ast.Expression)}
+
+	for !p.peek.Is(token.RBRACE) {
+		p.next()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.next()
+		hash.Pairs[key] = p.parseExpression(LOWEST)
+		hash.Keys = append(hash.Keys, key)
+
+		if !p.peek.Is(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
 	}
+
+	return hash
 }
 
 func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
@@ -330,18 +526,49 @@ func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
 }
 
 func (p *Parser) parseCallExpression(fn ast.Expression) ast.Expression {
-	return &ast.CallExpression{
-		Token: p.cur,
-		Func:  fn,
-		Args:  p.parseExpressionList(token.RPAREN),
+	var call = &ast.CallExpression{Token: p.cur, Func: fn}
+
+	// The arguments are parsed apart since they move the current token.
+	call.Args = p.parseExpressionList(token.RPAREN)
+	return call
+}
+
+// Parses x.name as the index expression x["name"].
+func (p *Parser) parseDotExpression(left ast.Expression) ast.Expression {
+	var exp = &ast.IndexExpression{Token: p.cur, Left: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
 	}
+	tok := token.Token{Typ: token.STRING, Lit: p.cur.Lit, Pos: p.cur.Pos}
+	exp.Index = &ast.StringLiteral{Token: tok, Value: p.cur.Lit}
+	return exp
 }
 
+// Parses either an index expression or a slice expression like a[1:3].
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	var exp = &ast.IndexExpression{Token: p.cur, Left: left}
+	var tok = p.cur
+	var start ast.Expression
 
 	p.next()
-	exp.Index = p.parseExpression(LOWEST)
+	if !p.cur.Is(token.COLON) {
+		start = p.parseExpression(LOWEST)
+
+		if !p.peek.Is(token.COLON) {
+			if !p.expectPeek(token.RBRACKET) {
+				return nil
+			}
+			return &ast.IndexExpression{Token: tok, Left: left, Index: start}
+		}
+		p.next()
+	}
+
+	var exp = &ast.SliceExpression{Token: tok, Left: left, Start: start}
+	exp.End = p.parseSliceBound()
+	if p.peek.Is(token.COLON) {
+		p.next()
+		exp.Step = p.parseSliceBound()
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
@@ -350,11 +577,21 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// Parses the optional bound of a slice that follows the current colon.
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peek.Is(token.COLON) || p.peek.Is(token.RBRACKET) {
+		return nil
+	}
+	p.next()
+	return p.parseExpression(LOWEST)
+}
+
 // Return a *ast.BlockStatement representing a block enclosed in braces.
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	var block = &ast.BlockStatement{Token: p.cur}
 
 	p.next()
+	p.depth++
 
 	for !p.cur.Is(token.RBRACE) && !p.cur.Is(token.EOF) {
 		if s := p.parseStatement(); s != nil {
@@ -362,6 +599,7 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		p.next()
 	}
+	p.depth--
 	return block
 }
 
@@ -375,12 +613,12 @@ func (p *Parser) parseFunctionParams() []*ast.Identifier {
 	}
 
 	p.next()
-	ret = append(ret, &ast.Identifier{Token: p.cur, Value: p.cur.Lit})
+	ret = append(ret, p.parseFunctionParam())
 
 	for p.peek.Is(token.COMMA) {
 		p.next()
 		p.next()
-		ret = append(ret, &ast.Identifier{Token: p.cur, Value: p.cur.Lit})
+		ret = append(ret, p.parseFunctionParam())
 	}
 
 	if !p.expectPeek(token.RPAREN) {
@@ -389,6 +627,74 @@ func (p *Parser) parseFunctionParams() []*ast.Identifier {
 	return ret
 }
 
+// Returns the identifier of a function parameter with its optional type.
+func (p *Parser) parseFunctionParam() *ast.Identifier {
+	var id = &ast.Identifier{Token: p.cur, Value: p.cur.Lit}
+
+	if p.peek.Is(token.COLON) {
+		p.next()
+		id.Type = p.parseTypeAnnotation()
+	}
+	return id
+}
+
+// Parses the type following the current token, which is either ':' or '->'.
+func (p *Parser) parseTypeAnnotation() *ast.Type {
+	p.next()
+	return p.parseType()
+}
+
+// Returns the type starting at the current token.
+func (p *Parser) parseType() *ast.Type {
+	var t = &ast.Type{Token: p.cur, Name: p.cur.Lit}
+
+	switch p.cur.Typ {
+	case token.IDENT:
+		return t
+
+	case token.LBRACKET:
+		p.next()
+		if t.Elem = p.parseType(); t.Elem == nil {
+			return nil
+		}
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return t
+
+	case token.FUNCTION:
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		if p.peek.Is(token.RPAREN) {
+			p.next()
+		} else {
+			p.next()
+			t.Params = append(t.Params, p.parseType())
+			for p.peek.Is(token.COMMA) {
+				p.next()
+				p.next()
+				t.Params = append(t.Params, p.parseType())
+			}
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+		if p.peek.Is(token.ARROW) {
+			p.next()
+			if t.Ret = p.parseTypeAnnotation(); t.Ret == nil {
+				return nil
+			}
+		}
+		return t
+
+	default:
+		msg := fmt.Sprintf("expected a type, got %s instead", p.cur.Typ)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
 // Returns true if the peek token is of type 't'.
 func (p *Parser) expectPeek(t token.Type) bool {
 	if p.peek.Is(t) {
@@ -278,6 +278,102 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerLiteralBases(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"1_000_000", 1000000},
+		{"0x1F", 31},
+		{"0XFF_FF", 65535},
+		{"0o755", 493},
+		{"0O1_0", 8},
+		{"0b1010", 10},
+		{"0B1111_0000", 240},
+		{"010", 10},
+		{"0x7FFF_FFFF_FFFF_FFFF", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.Lex(tt.input))
+		prog := p.Parse()
+		checkParserErrors(t, p)
+
+		literal := prog.Statements[0].(*ast.ExpressionStatement).Expr.(*ast.IntegerLiteral)
+		if literal.Value != tt.expected {
+			t.Errorf("%s: literal.Value not %d, got %d", tt.input, tt.expected, literal.Value)
+		}
+	}
+
+	p := New(lexer.Lex("0x1_0000_0000_0000_0000"))
+	prog := p.Parse()
+	checkParserErrors(t, p)
+	literal := prog.Statements[0].(*ast.ExpressionStatement).Expr.(*ast.IntegerLiteral)
+	if literal.Big == nil || literal.Big.String() != "18446744073709551616" {
+		t.Errorf("literal.Big not 18446744073709551616, got %v", literal.Big)
+	}
+
+	p = New(lexer.Lex("let x = 0xZZ;"))
+	p.Parse()
+	if errs := p.Errors(); len(errs) == 0 || errs[0] != `lexer: malformed number "0xZZ"` {
+		t.Errorf("expected malformed number error, got %q", errs)
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"0.1", 0.1},
+		{"1_000.5", 1000.5},
+		{"1e3", 1000},
+		{"2.5e-1", 0.25},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.Lex(tt.input))
+		prog := p.Parse()
+		checkParserErrors(t, p)
+
+		literal, ok := prog.Statements[0].(*ast.ExpressionStatement).Expr.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("%s: exp not *ast.FloatLiteral, got %T", tt.input, prog.Statements[0].(*ast.ExpressionStatement).Expr)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("%s: literal.Value not %g, got %g", tt.input, tt.expected, literal.Value)
+		}
+	}
+
+	p := New(lexer.Lex("1e999"))
+	p.Parse()
+	if errs := p.Errors(); len(errs) == 0 || errs[0] != `could not parse "1e999" as a float` {
+		t.Errorf("expected float parse error, got %q", errs)
+	}
+}
+
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := "123456789012345678901234567890"
+
+	p := New(lexer.Lex(input))
+	prog := p.Parse()
+	checkParserErrors(t, p)
+
+	stmt := prog.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expr.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.IntegerLiteral, got %T", stmt.Expr)
+	}
+
+	if literal.Big == nil || literal.Big.String() != input {
+		t.Errorf("literal.Big not %s, got %v", input, literal.Big)
+	}
+	if literal.String() != input {
+		t.Errorf("literal.String() not %s, got %s", input, literal.String())
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := `true;`
 
@@ -698,6 +794,119 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expr, "x", "+", "y")
 }
 
+func TestTryThrow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`throw "boom";`, "throw boom;"},
+		{`throw error("a", "b")`, "throw error(a, b);"},
+		{"try { f(x) } catch (e) { e }", "try f(x) catch (e) e"},
+		{"let v = try { 1 / 0 } catch (err) { 0 };", "let v = try (1 / 0) catch (err) 0;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.Lex(tt.input))
+		program := p.Parse()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	for _, input := range []string{"try { 1 }", "try { 1 } catch { 2 }", "try { 1 } catch (1) { 2 }", "try 1 catch (e) { 2 }"} {
+		p := New(lexer.Lex(input))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected parser errors", input)
+		}
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "math";`, `import "math" as math;`},
+		{`import "lib/str_utils.mk"`, `import "lib/str_utils.mk" as str_utils;`},
+		{`import "../lib/list" as l;`, `import "../lib/list" as l;`},
+		{"export let x = 1;", "export let x = 1;"},
+		{"m.x", "(m[x])"},
+		{"m.f(1).y", "((m[f])(1)[y])"},
+		{"a.b.c + 1", "(((a[b])[c]) + 1)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.Lex(tt.input))
+		program := p.Parse()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	for _, input := range []string{
+		`import math`,
+		`import "my-lib"`,
+		`import "lib/if.mk"`,
+		`import "x" as "y"`,
+		"fn() { export let x = 1; }",
+		"if (true) { export let x = 1; }",
+		"if (true) { 1 } else { export let x = 1; }",
+		"try { export let x = 1; } catch (e) { 1 }",
+		"export 1",
+		"m.1",
+	} {
+		p := New(lexer.Lex(input))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected parser errors", input)
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let x: [string] = [];", "let x: [string] = [];"},
+		{"let x: [[int]] = [[1]];", "let x: [[int]] = [[1]];"},
+		{
+			"fn(a: string, b: [int]) -> bool { true }",
+			"fn(a: string, b: [int]) -> bool true",
+		},
+		{"fn(a, b: int) { a }", "fn(a, b: int) a"},
+		{
+			"let f: fn(int, int) -> int = fn(a, b) { a + b };",
+			"let f: fn(int, int) -> int = fn(a, b) (a + b);",
+		},
+		{"let f: fn() = fn() { 1 };", "let f: fn() = fn() 1;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.Lex(tt.input))
+		program := p.Parse()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	// Let statements and parameters keep their annotation on the name.
+	p := New(lexer.Lex("let x: int = 5;"))
+	let := p.Parse().Statements[0].(*ast.LetStatement)
+	checkParserErrors(t, p)
+	if let.Name.Type == nil || let.Name.Type.String() != "int" {
+		t.Errorf("wrong annotation of x, got %v", let.Name.Type)
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -797,6 +1006,42 @@ func TestParsingArrayLiterals(t *testing.T) {
 	testInfixExpression(t, array.Elements[2], 3, "+", 3)
 }
 
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[1:3]", "(a[1:3])"},
+		{"a[:3]", "(a[:3])"},
+		{"a[1:]", "(a[1:])"},
+		{"a[:]", "(a[:])"},
+		{"a[::2]", "(a[::2])"},
+		{"a[1::2]", "(a[1::2])"},
+		{"a[:-1:]", "(a[:(-1)])"},
+		{"a[i + 1:len(a) - 1]", "(a[(i + 1):(len(a) - 1)])"},
+		{"\"hello\"[1:3][0]", "((hello[1:3])[0])"},
+	}
+
+	for _, tt := range tests {
+		toks := lexer.Lex(tt.input)
+		p := New(toks)
+		program := p.Parse()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, actual)
+		}
+	}
+
+	for _, input := range []string{"a[1:2:3:4]", "a[1 2]", "a[1:2"} {
+		p := New(lexer.Lex(input))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected parser errors", input)
+		}
+	}
+}
+
 func TestParsingIndexExpressions(t *testing.T) {
 	input := "myArray[1 + 1]"
 
@@ -820,179 +1065,179 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
-// func TestParsingEmptyHashLiteral(t *testing.T) {
-// 	input := "{}"
+func TestParsingEmptyHashLiteral(t *testing.T) {
+	input := "{}"
 
-// 	l := lexer.New(input)
-// 	p := New(l)
-// 	program := p.ParseProgram()
-// 	checkParserErrors(t, p)
+	toks := lexer.Lex(input)
+	p := New(toks)
+	program := p.Parse()
+	checkParserErrors(t, p)
 
-// 	stmt := program.Statements[0].(*ast.ExpressionStatement)
-// 	hash, ok := stmt.Expr.(*ast.HashLiteral)
-// 	if !ok {
-// 		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
-// 	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expr.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
+	}
 
-// 	if len(hash.Pairs) != 0 {
-// 		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
-// 	}
-// }
+	if len(hash.Pairs) != 0 {
+		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
+	}
+}
 
-// func TestParsingHashLiteralsStringKeys(t *testing.T) {
-// 	input := `{"one": 1, "two": 2, "three": 3}`
+func TestParsingHashLiteralsStringKeys(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
 
-// 	l := lexer.New(input)
-// 	p := New(l)
-// 	program := p.ParseProgram()
-// 	checkParserErrors(t, p)
+	toks := lexer.Lex(input)
+	p := New(toks)
+	program := p.Parse()
+	checkParserErrors(t, p)
 
-// 	stmt := program.Statements[0].(*ast.ExpressionStatement)
-// 	hash, ok := stmt.Expr.(*ast.HashLiteral)
-// 	if !ok {
-// 		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
-// 	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expr.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
+	}
 
-// 	expected := map[string]int64{
-// 		"one":   1,
-// 		"two":   2,
-// 		"three": 3,
-// 	}
+	expected := map[string]int64{
+		"one":   1,
+		"two":   2,
+		"three": 3,
+	}
 
-// 	if len(hash.Pairs) != len(expected) {
-// 		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
-// 	}
+	if len(hash.Pairs) != len(expected) {
+		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
+	}
 
-// 	for key, value := range hash.Pairs {
-// 		literal, ok := key.(*ast.StringLiteral)
-// 		if !ok {
-// 			t.Errorf("key is not ast.StringLiteral, got %T", key)
-// 			continue
-// 		}
+	for key, value := range hash.Pairs {
+		literal, ok := key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral, got %T", key)
+			continue
+		}
 
-// 		expectedValue := expected[literal.String()]
-// 		testIntegerLiteral(t, value, expectedValue)
-// 	}
-// }
+		expectedValue := expected[literal.String()]
+		testIntegerLiteral(t, value, expectedValue)
+	}
+}
 
-// func TestParsingHashLiteralsBooleanKeys(t *testing.T) {
-// 	input := `{true: 1, false: 2}`
+func TestParsingHashLiteralsBooleanKeys(t *testing.T) {
+	input := `{true: 1, false: 2}`
 
-// 	l := lexer.New(input)
-// 	p := New(l)
-// 	program := p.ParseProgram()
-// 	checkParserErrors(t, p)
+	toks := lexer.Lex(input)
+	p := New(toks)
+	program := p.Parse()
+	checkParserErrors(t, p)
 
-// 	stmt := program.Statements[0].(*ast.ExpressionStatement)
-// 	hash, ok := stmt.Expr.(*ast.HashLiteral)
-// 	if !ok {
-// 		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
-// 	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expr.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
+	}
 
-// 	expected := map[string]int64{
-// 		"true":  1,
-// 		"false": 2,
-// 	}
+	expected := map[string]int64{
+		"true":  1,
+		"false": 2,
+	}
 
-// 	if len(hash.Pairs) != len(expected) {
-// 		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
-// 	}
+	if len(hash.Pairs) != len(expected) {
+		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
+	}
 
-// 	for key, value := range hash.Pairs {
-// 		boolean, ok := key.(*ast.Boolean)
-// 		if !ok {
-// 			t.Errorf("key is not ast.BooleanLiteral, got %T", key)
-// 			continue
-// 		}
+	for key, value := range hash.Pairs {
+		boolean, ok := key.(*ast.Boolean)
+		if !ok {
+			t.Errorf("key is not ast.BooleanLiteral, got %T", key)
+			continue
+		}
 
-// 		expectedValue := expected[boolean.String()]
-// 		testIntegerLiteral(t, value, expectedValue)
-// 	}
-// }
+		expectedValue := expected[boolean.String()]
+		testIntegerLiteral(t, value, expectedValue)
+	}
+}
 
-// func TestParsingHashLiteralsIntegerKeys(t *testing.T) {
-// 	input := `{1: 1, 2: 2, 3: 3}`
+func TestParsingHashLiteralsIntegerKeys(t *testing.T) {
+	input := `{1: 1, 2: 2, 3: 3}`
 
-// 	l := lexer.New(input)
-// 	p := New(l)
-// 	program := p.ParseProgram()
-// 	checkParserErrors(t, p)
+	toks := lexer.Lex(input)
+	p := New(toks)
+	program := p.Parse()
+	checkParserErrors(t, p)
 
-// 	stmt := program.Statements[0].(*ast.ExpressionStatement)
-// 	hash, ok := stmt.Expr.(*ast.HashLiteral)
-// 	if !ok {
-// 		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
-// 	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expr.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
+	}
 
-// 	expected := map[string]int64{
-// 		"1": 1,
-// 		"2": 2,
-// 		"3": 3,
-// 	}
+	expected := map[string]int64{
+		"1": 1,
+		"2": 2,
+		"3": 3,
+	}
 
-// 	if len(hash.Pairs) != len(expected) {
-// 		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
-// 	}
+	if len(hash.Pairs) != len(expected) {
+		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
+	}
 
-// 	for key, value := range hash.Pairs {
-// 		integer, ok := key.(*ast.IntegerLiteral)
-// 		if !ok {
-// 			t.Errorf("key is not ast.IntegerLiteral, got %T", key)
-// 			continue
-// 		}
+	for key, value := range hash.Pairs {
+		integer, ok := key.(*ast.IntegerLiteral)
+		if !ok {
+			t.Errorf("key is not ast.IntegerLiteral, got %T", key)
+			continue
+		}
 
-// 		expectedValue := expected[integer.String()]
+		expectedValue := expected[integer.String()]
 
-// 		testIntegerLiteral(t, value, expectedValue)
-// 	}
-// }
+		testIntegerLiteral(t, value, expectedValue)
+	}
+}
 
-// func TestParsingHashLiteralsWithExpressions(t *testing.T) {
-// 	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`
 
-// 	l := lexer.New(input)
-// 	p := New(l)
-// 	program := p.ParseProgram()
-// 	checkParserErrors(t, p)
+	toks := lexer.Lex(input)
+	p := New(toks)
+	program := p.Parse()
+	checkParserErrors(t, p)
 
-// 	stmt := program.Statements[0].(*ast.ExpressionStatement)
-// 	hash, ok := stmt.Expr.(*ast.HashLiteral)
-// 	if !ok {
-// 		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
-// 	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expr.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral, got %T", stmt.Expr)
+	}
 
-// 	if len(hash.Pairs) != 3 {
-// 		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
-// 	}
+	if len(hash.Pairs) != 3 {
+		t.Errorf("hash.Pairs has wrong length, got %d", len(hash.Pairs))
+	}
 
-// 	tests := map[string]func(ast.Expression){
-// 		"one": func(e ast.Expression) {
-// 			testInfixExpression(t, e, 0, "+", 1)
-// 		},
-// 		"two": func(e ast.Expression) {
-// 			testInfixExpression(t, e, 10, "-", 8)
-// 		},
-// 		"three": func(e ast.Expression) {
-// 			testInfixExpression(t, e, 15, "/", 5)
-// 		},
-// 	}
+	tests := map[string]func(ast.Expression){
+		"one": func(e ast.Expression) {
+			testInfixExpression(t, e, 0, "+", 1)
+		},
+		"two": func(e ast.Expression) {
+			testInfixExpression(t, e, 10, "-", 8)
+		},
+		"three": func(e ast.Expression) {
+			testInfixExpression(t, e, 15, "/", 5)
+		},
+	}
 
-// 	for key, value := range hash.Pairs {
-// 		literal, ok := key.(*ast.StringLiteral)
-// 		if !ok {
-// 			t.Errorf("key is not ast.StringLiteral, got %T", key)
-// 			continue
-// 		}
+	for key, value := range hash.Pairs {
+		literal, ok := key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral, got %T", key)
+			continue
+		}
 
-// 		testFunc, ok := tests[literal.String()]
-// 		if !ok {
-// 			t.Errorf("No test function for key %q found", literal.String())
-// 			continue
-// 		}
+		testFunc, ok := tests[literal.String()]
+		if !ok {
+			t.Errorf("No test function for key %q found", literal.String())
+			continue
+		}
 
-// 		testFunc(value)
-// 	}
-// }
+		testFunc(value)
+	}
+}
 
 // func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 // 	if s.TokenLiteral() != "let" {
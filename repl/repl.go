@@ -5,6 +5,7 @@ import (
 	"github.com/NicoNex/monkey/evaluator"
 	"github.com/NicoNex/monkey/lexer"
 	"github.com/NicoNex/monkey/obj"
+	"github.com/NicoNex/monkey/optimize"
 	"github.com/NicoNex/monkey/parser"
 	"io"
 	"os"
@@ -18,8 +19,34 @@ func printParserErrors(errs []string, out io.Writer) {
 	}
 }
 
+// Reads the input of the scripts from the terminal one line at a time.
+type termReader struct {
+	term *terminal.Terminal
+	buf  []byte
+}
+
+func (r *termReader) Read(b []byte) (int, error) {
+	if len(r.buf) == 0 {
+		// The prompt is written by the script.
+		r.term.SetPrompt("")
+		line, err := r.term.ReadLine()
+		r.term.SetPrompt(prompt)
+		if err != nil {
+			return 0, err
+		}
+		r.buf = []byte(line + "\n")
+	}
+
+	n := copy(b, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+const prompt = ">>> "
+
 func Run() {
 	var env = obj.NewEnv()
+	var in = evaluator.New()
 	var initState *terminal.State
 
 	initState, err := terminal.MakeRaw(0)
@@ -29,7 +56,19 @@ func Run() {
 	}
 	defer terminal.Restore(0, initState)
 
-	term := terminal.NewTerminal(os.Stdin, ">>> ")
+	term := terminal.NewTerminal(os.Stdin, prompt)
+	// The terminal is in raw mode, so the scripts go through it to have
+	// their newlines translated and their input echoed.
+	in.Stdout = term
+	in.Stdin = &termReader{term: term}
+	// Scripts can work on the files in the current directory.
+	if dir, err := evaluator.DirFS("."); err == nil {
+		in.FS = dir
+	}
+	// The standard library modules are ready to use without importing them.
+	if err := in.LoadStdlib(env); err != nil {
+		fmt.Fprintln(term, err.Inspect())
+	}
 	for {
 		input, err := term.ReadLine()
 		if err != nil {
@@ -49,7 +88,13 @@ func Run() {
 			continue
 		}
 
-		if val := evaluator.Eval(prog, env); val != nil {
+		optimize.Optimize(prog)
+		val := in.Eval(prog, env)
+		if status, ok := in.ExitStatus(val); ok {
+			terminal.Restore(0, initState)
+			os.Exit(status)
+		}
+		if val != nil {
 			fmt.Fprintln(term, val.Inspect())
 		}
 	}
@@ -0,0 +1,184 @@
+// Package resolver binds each local variable of a Monkey program to a slot
+// of the environment of the function declaring it, so that the evaluator can
+// find it by index instead of looking it up by name.
+//
+// A reference to a name declared earlier in the same function resolves to
+// that function's slot. Function bodies are resolved only after the scope
+// enclosing them is complete, since they run when they're called, so they
+// can refer to variables of the enclosing functions declared after them.
+// Until such a variable is set, the reference falls back to the binding of
+// the name further out. Whatever isn't declared by any enclosing function
+// is a global.
+package resolver
+
+import "github.com/NicoNex/monkey/ast"
+
+type scope struct {
+	outer *scope
+	// Slot of each local variable, nil in the global scope.
+	slots map[string]int
+	// Functions whose body gets resolved once the scope is complete.
+	deferred []*ast.FunctionLiteral
+}
+
+// Resolve annotates the identifiers and function literals in prog.
+func Resolve(prog *ast.Program) {
+	var global = &scope{}
+
+	for _, s := range prog.Statements {
+		statement(s, global)
+	}
+	global.close()
+}
+
+// Returns the slot of the local variable name in s, declaring it if needed.
+func (s *scope) declare(name string) int {
+	if idx, ok := s.slots[name]; ok {
+		return idx
+	}
+	idx := len(s.slots)
+	s.slots[name] = idx
+	return idx
+}
+
+func function(fn *ast.FunctionLiteral, outer *scope) {
+	var s = &scope{outer: outer, slots: make(map[string]int)}
+
+	for _, p := range fn.Params {
+		local(p, s)
+	}
+	for _, stmt := range fn.Body.Statements {
+		statement(stmt, s)
+	}
+	s.close()
+	fn.Slots = len(s.slots)
+}
+
+// Resolves the bodies of the functions declared in s.
+func (s *scope) close() {
+	// Function bodies can declare more functions so the list may grow.
+	for i := 0; i < len(s.deferred); i++ {
+		function(s.deferred[i], s)
+	}
+}
+
+// Binds id to a new slot of s.
+func local(id *ast.Identifier, s *scope) {
+	id.Local = true
+	id.Depth = 0
+	id.Index = s.declare(id.Value)
+}
+
+func statement(stmt ast.Statement, s *scope) {
+	switch stmt := stmt.(type) {
+
+	case *ast.LetStatement:
+		expression(stmt.Value, s)
+		if s.slots != nil {
+			local(stmt.Name, s)
+		}
+
+	case *ast.ImportStatement:
+		if s.slots != nil {
+			local(stmt.Name, s)
+		}
+
+	case *ast.ReturnStatement:
+		expression(stmt.Value, s)
+
+	case *ast.ThrowStatement:
+		expression(stmt.Value, s)
+
+	case *ast.ExpressionStatement:
+		expression(stmt.Expr, s)
+
+	case *ast.BlockStatement:
+		block(stmt, s)
+	}
+}
+
+func block(b *ast.BlockStatement, s *scope) {
+	if b == nil {
+		return
+	}
+	for _, stmt := range b.Statements {
+		statement(stmt, s)
+	}
+}
+
+func expression(expr ast.Expression, s *scope) {
+	switch expr := expr.(type) {
+
+	case *ast.Identifier:
+		identifier(expr, s)
+
+	case *ast.PrefixExpression:
+		expression(expr.Right, s)
+
+	case *ast.InfixExpression:
+		expression(expr.Left, s)
+		expression(expr.Right, s)
+
+	case *ast.IfExpression:
+		expression(expr.Condition, s)
+		block(expr.Consequence, s)
+		block(expr.Alternative, s)
+
+	case *ast.TryExpression:
+		block(expr.Body, s)
+		// The error is bound like a let statement would bind it.
+		if s.slots != nil {
+			local(expr.Param, s)
+		}
+		block(expr.Catch, s)
+
+	case *ast.FunctionLiteral:
+		s.deferred = append(s.deferred, expr)
+
+	case *ast.CallExpression:
+		expression(expr.Func, s)
+		for _, a := range expr.Args {
+			expression(a, s)
+		}
+
+	case *ast.ArrayLiteral:
+		for _, e := range expr.Elements {
+			expression(e, s)
+		}
+
+	case *ast.IndexExpression:
+		expression(expr.Left, s)
+		expression(expr.Index, s)
+
+	case *ast.SliceExpression:
+		expression(expr.Left, s)
+		expression(expr.Start, s)
+		expression(expr.End, s)
+		expression(expr.Step, s)
+
+	case *ast.HashLiteral:
+		for _, k := range expr.Keys {
+			expression(k, s)
+			expression(expr.Pairs[k], s)
+		}
+	}
+}
+
+func identifier(id *ast.Identifier, s *scope) {
+	var b = id
+
+	id.Local = false
+	id.Outer = nil
+	for depth := 0; s.slots != nil; depth++ {
+		if idx, ok := s.slots[id.Value]; ok {
+			if b.Local {
+				b.Outer = &ast.Identifier{Token: id.Token, Value: id.Value}
+				b = b.Outer
+			}
+			b.Local = true
+			b.Depth = depth
+			b.Index = idx
+		}
+		s = s.outer
+	}
+}
@@ -0,0 +1,163 @@
+package resolver_test
+
+import (
+	"testing"
+
+	"github.com/NicoNex/monkey/ast"
+	"github.com/NicoNex/monkey/lexer"
+	"github.com/NicoNex/monkey/parser"
+)
+
+type binding struct {
+	name  string
+	local bool
+	depth int
+	index int
+}
+
+// Collects the identifiers in the order they appear in the source.
+func collect(n ast.Node, ids *[]*ast.Identifier) {
+	switch n := n.(type) {
+	case *ast.Program:
+		for _, s := range n.Statements {
+			collect(s, ids)
+		}
+	case *ast.BlockStatement:
+		for _, s := range n.Statements {
+			collect(s, ids)
+		}
+	case *ast.LetStatement:
+		*ids = append(*ids, n.Name)
+		collect(n.Value, ids)
+	case *ast.ReturnStatement:
+		collect(n.Value, ids)
+	case *ast.ExpressionStatement:
+		collect(n.Expr, ids)
+	case *ast.Identifier:
+		*ids = append(*ids, n)
+	case *ast.InfixExpression:
+		collect(n.Left, ids)
+		collect(n.Right, ids)
+	case *ast.PrefixExpression:
+		collect(n.Right, ids)
+	case *ast.IfExpression:
+		collect(n.Condition, ids)
+		collect(n.Consequence, ids)
+		if n.Alternative != nil {
+			collect(n.Alternative, ids)
+		}
+	case *ast.FunctionLiteral:
+		for _, p := range n.Params {
+			*ids = append(*ids, p)
+		}
+		collect(n.Body, ids)
+	case *ast.CallExpression:
+		collect(n.Func, ids)
+		for _, a := range n.Args {
+			collect(a, ids)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []binding
+	}{
+		{
+			"let a = 1; a;",
+			[]binding{{"a", false, 0, 0}, {"a", false, 0, 0}},
+		},
+		{
+			"fn(a, b) { let c = a; b + c }",
+			[]binding{
+				{"a", true, 0, 0}, {"b", true, 0, 1},
+				{"c", true, 0, 2}, {"a", true, 0, 0},
+				{"b", true, 0, 1}, {"c", true, 0, 2},
+			},
+		},
+		{
+			// Closures reach the slots of the enclosing functions.
+			"fn(a) { fn(b) { fn() { a + b + c } } }",
+			[]binding{
+				{"a", true, 0, 0}, {"b", true, 0, 0},
+				{"a", true, 2, 0}, {"b", true, 1, 0}, {"c", false, 0, 0},
+			},
+		},
+		{
+			// A name refers to the local only after it's declared.
+			"fn() { let y = x; let x = 2; x }",
+			[]binding{
+				{"y", true, 0, 0}, {"x", false, 0, 0},
+				{"x", true, 0, 1}, {"x", true, 0, 1},
+			},
+		},
+		{
+			// Rebinding a local reuses its slot.
+			"fn(x) { let x = x + 1; x }",
+			[]binding{
+				{"x", true, 0, 0}, {"x", true, 0, 0},
+				{"x", true, 0, 0}, {"x", true, 0, 0},
+			},
+		},
+		{
+			// Nested functions see the whole enclosing scope.
+			"fn() { let f = fn() { g }; let g = 1; f }",
+			[]binding{
+				{"f", true, 0, 0}, {"g", true, 1, 1},
+				{"g", true, 0, 1}, {"f", true, 0, 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.Lex(tt.input))
+		prog := p.Parse()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Fatalf("parser errors: %v", errs)
+		}
+
+		var ids []*ast.Identifier
+		collect(prog, &ids)
+
+		if len(ids) != len(tt.expected) {
+			t.Fatalf("%q: wrong number of identifiers, got %d, want %d", tt.input, len(ids), len(tt.expected))
+		}
+		for i, id := range ids {
+			got := binding{id.Value, id.Local, id.Depth, id.Index}
+			if got != tt.expected[i] {
+				t.Errorf("%q: identifier %d: got %+v, want %+v", tt.input, i, got, tt.expected[i])
+			}
+		}
+	}
+}
+
+func TestSlots(t *testing.T) {
+	p := parser.New(lexer.Lex("fn(a, b) { let c = 1; let a = 2; if (c) { let d = 3 }; fn(e) { e } }"))
+	prog := p.Parse()
+
+	fn := prog.Statements[0].(*ast.ExpressionStatement).Expr.(*ast.FunctionLiteral)
+	if fn.Slots != 4 {
+		t.Errorf("wrong number of slots, got %d, want 4", fn.Slots)
+	}
+}
+
+func TestOuter(t *testing.T) {
+	p := parser.New(lexer.Lex("fn(x) { fn() { let g = fn() { x }; let x = 1 } }"))
+	prog := p.Parse()
+
+	// The x inside g, which is declared by both functions enclosing it.
+	outer := prog.Statements[0].(*ast.ExpressionStatement).Expr.(*ast.FunctionLiteral)
+	inner := outer.Body.Statements[0].(*ast.ExpressionStatement).Expr.(*ast.FunctionLiteral)
+	g := inner.Body.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	x := g.Body.Statements[0].(*ast.ExpressionStatement).Expr.(*ast.Identifier)
+
+	var got []binding
+	for id := x; id != nil; id = id.Outer {
+		got = append(got, binding{id.Value, id.Local, id.Depth, id.Index})
+	}
+	want := []binding{{"x", true, 1, 1}, {"x", true, 2, 0}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("wrong bindings, got %+v, want %+v", got, want)
+	}
+}
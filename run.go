@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NicoNex/monkey/evaluator"
+	"github.com/NicoNex/monkey/obj"
+)
+
+// Evaluates the file in args and returns the exit status of the run
+// command.
+func run(args []string) int {
+	var fs = flag.NewFlagSet("run", flag.ContinueOnError)
+	var seed = fs.Int64("seed", 0, "seed of the random builtins (default the current time)")
+	var root = fs.String("root", ".", "directory the script, its imports and the file builtins are confined to")
+	var env = fs.String("env", "", "comma-separated `names` of the environment variables visible to the script")
+	var profile = fs.String("profile", evaluator.Full.Name, "capabilities granted to the script: pure, read-only or full")
+	var limits evaluator.Limits
+	fs.IntVar(&limits.MaxSteps, "max-steps", 0, "maximum number of evaluation steps (default no limit)")
+	fs.IntVar(&limits.MaxDepth, "max-depth", 0, "maximum depth of the function calls (default no limit)")
+	fs.IntVar(&limits.MaxLen, "max-len", 0, "maximum length of the strings and arrays (default no limit)")
+	fs.DurationVar(&limits.Timeout, "timeout", 0, "maximum duration of the evaluation (default no limit)")
+
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: monkey run [flags] file [args...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	p, ok := evaluator.LookupProfile(*profile)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown profile: %s\n", *profile)
+		return 2
+	}
+
+	in := evaluator.New()
+	in.SetProfile(p)
+	in.Args = fs.Args()[1:]
+	if *env != "" {
+		in.Env = evaluator.HostEnv(strings.Split(*env, ",")...)
+	}
+	dir, err := evaluator.DirFS(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	in.FS = dir
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			in.Seed(*seed)
+		}
+	})
+
+	path := fs.Arg(0)
+	name, err := rootPath(*root, path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	in.SetLimits(limits)
+	ret := in.EvalFile(name, obj.NewEnv())
+	if status, ok := in.ExitStatus(ret); ok {
+		return status
+	}
+	if err, ok := ret.(*obj.Error); ok && !err.Caught {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err.Msg)
+		return 1
+	}
+	return 0
+}
+
+// Returns the path of the file p relative to the directory root, which the
+// scripts and their imports are confined to.
+func rootPath(root, p string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(absRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: file outside the root %s", p, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
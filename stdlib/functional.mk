@@ -0,0 +1,25 @@
+export let identity = fn(x) { x };
+
+export let constant = fn(x) {
+	fn(_) { x }
+};
+
+export let compose = fn(f, g) {
+	fn(x) { f(g(x)) }
+};
+
+export let pipe = fn(fns) {
+	fn(x) { reduce(fns, fn(acc, f) { f(acc) }, x) }
+};
+
+export let flip = fn(f) {
+	fn(a, b) { f(b, a) }
+};
+
+export let partial = fn(f, a) {
+	fn(b) { f(a, b) }
+};
+
+export let negate = fn(pred) {
+	fn(x) { !pred(x) }
+};
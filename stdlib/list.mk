@@ -0,0 +1,41 @@
+export let range = fn(start, stop) {
+	let loop = fn(acc, i) {
+		if (i >= stop) { acc } else { loop(push(acc, i), i + 1) }
+	};
+	loop([], start)
+};
+
+export let take = fn(arr, n) {
+	if (n <= 0) { return []; }
+	if (n >= len(arr)) { return slice(arr, 0); }
+	slice(arr, 0, n)
+};
+
+export let drop = fn(arr, n) {
+	if (n <= 0) { return slice(arr, 0); }
+	if (n >= len(arr)) { return []; }
+	slice(arr, n)
+};
+
+export let zip = fn(a, b) {
+	let n = if (len(a) < len(b)) { len(a) } else { len(b) };
+	map(range(0, n), fn(i) { [a[i], b[i]] })
+};
+
+export let unique = fn(arr) {
+	reduce(arr, fn(acc, x) {
+		if (contains(acc, x)) { acc } else { push(acc, x) }
+	}, [])
+};
+
+export let count = fn(arr, pred) {
+	len(filter(arr, pred))
+};
+
+export let chunk = fn(arr, size) {
+	if (size <= 0) { throw error("chunk: size must be positive", "value"); }
+	let loop = fn(acc, tail) {
+		if (len(tail) == 0) { acc } else { loop(push(acc, take(tail, size)), drop(tail, size)) }
+	};
+	loop([], arr)
+};
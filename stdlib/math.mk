@@ -0,0 +1,32 @@
+export let mod = fn(a, b) {
+	let r = a - a / b * b;
+	if (r == 0) { return 0; }
+	if ((r < 0) != (b < 0)) { r + b } else { r }
+};
+
+export let is_even = fn(n) {
+	mod(n, 2) == 0
+};
+
+export let is_odd = fn(n) {
+	mod(n, 2) == 1
+};
+
+export let sign = fn(n) {
+	if (n > 0) { 1 } else { if (n < 0) { -1 } else { 0 } }
+};
+
+export let factorial = fn(n) {
+	if (n < 0) { throw error("factorial: negative argument", "value"); }
+	if (n < 2) { 1 } else { n * factorial(n - 1) }
+};
+
+export let choose = fn(n, k) {
+	if (k < 0) { return 0; }
+	if (k > n) { return 0; }
+	factorial(n) / (factorial(k) * factorial(n - k))
+};
+
+export let PI = 3.141592653589793;
+
+export let E = 2.718281828459045;
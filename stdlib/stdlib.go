@@ -0,0 +1,21 @@
+// Package stdlib embeds the standard library of Monkey, a set of modules
+// written in Monkey itself that programs import with the std/ prefix:
+//
+//	import "std/list";
+//	list.range(0, 10);
+//
+// The modules are:
+//
+//	list        range, take, drop, zip, unique, count, chunk
+//	string      capitalize, title, is_blank, center, reversed
+//	math        mod, is_even, is_odd, sign, factorial, choose, PI, E
+//	functional  identity, constant, compose, pipe, flip, partial, negate
+//	testing     assert, assert_eq, assert_throws, run
+package stdlib
+
+import "embed"
+
+// FS holds the source of the modules, one .mk file each.
+//
+//go:embed *.mk
+var FS embed.FS
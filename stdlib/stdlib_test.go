@@ -0,0 +1,159 @@
+package stdlib_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/NicoNex/monkey/evaluator"
+	"github.com/NicoNex/monkey/lexer"
+	"github.com/NicoNex/monkey/lint"
+	"github.com/NicoNex/monkey/obj"
+	"github.com/NicoNex/monkey/parser"
+	"github.com/NicoNex/monkey/stdlib"
+	"github.com/NicoNex/monkey/typecheck"
+)
+
+func testEval(t *testing.T, input string) obj.Object {
+	p := parser.New(lexer.Lex(input))
+	prog := p.Parse()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("%s: parser errors: %v", input, errs)
+	}
+	return evaluator.New().Eval(prog, obj.NewEnv())
+}
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "std/list"; list.range(0, 5)`, "[0, 1, 2, 3, 4]"},
+		{`import "std/list"; list.range(3, 1)`, "[]"},
+		{`import "std/list"; list.take([1, 2, 3], 2)`, "[1, 2]"},
+		{`import "std/list"; list.take([1, 2, 3], 5)`, "[1, 2, 3]"},
+		{`import "std/list"; list.take([1, 2, 3], -1)`, "[]"},
+		{`import "std/list"; list.drop([1, 2, 3], 1)`, "[2, 3]"},
+		{`import "std/list"; list.drop([1, 2, 3], 3)`, "[]"},
+		{`import "std/list"; list.zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`import "std/list"; list.unique([1, 2, 1, 3, 2])`, "[1, 2, 3]"},
+		{`import "std/list"; list.count([1, 2, 3, 4], fn(x) { x > 2 })`, "2"},
+		{`import "std/list"; list.chunk([1, 2, 3, 4, 5], 2)`, "[[1, 2], [3, 4], [5]]"},
+		{`import "std/list"; list.chunk([1], 0)`, "error: chunk: size must be positive"},
+
+		{`import "std/string"; string.capitalize("monkey")`, "Monkey"},
+		{`import "std/string"; string.capitalize("")`, ""},
+		{`import "std/string"; string.title("the  monkey language")`, "The Monkey Language"},
+		{`import "std/string"; string.is_blank("  ")`, "true"},
+		{`import "std/string"; string.is_blank(" a ")`, "false"},
+		{`import "std/string"; "[" + string.center("ab", 6) + "]"`, "[  ab  ]"},
+		{`import "std/string"; "[" + string.center("ab", 5) + "]"`, "[ ab  ]"},
+		{`import "std/string"; string.center("abc", 2)`, "abc"},
+		{`import "std/string"; string.reversed("héllo")`, "olléh"},
+
+		{`import "std/math"; math.mod(7, 3)`, "1"},
+		{`import "std/math"; math.mod(-7, 3)`, "2"},
+		{`import "std/math"; math.mod(7, -3)`, "-2"},
+		{`import "std/math"; math.mod(6, -3)`, "0"},
+		{`import "std/math"; [math.is_even(-4), math.is_odd(-3), math.is_odd(4)]`, "[true, true, false]"},
+		{`import "std/math"; [math.sign(-5), math.sign(0), math.sign(3)]`, "[-1, 0, 1]"},
+		{`import "std/math"; math.factorial(20)`, "2432902008176640000"},
+		{`import "std/math"; math.factorial(25)`, "15511210043330985984000000"},
+		{`import "std/math"; math.factorial(-1)`, "error: factorial: negative argument"},
+		{`import "std/math"; math.PI`, "3.141592653589793"},
+		{`import "std/math"; floor(math.E * 1000)`, "2718"},
+		{`import "std/math"; [math.choose(5, 2), math.choose(5, 6), math.choose(5, -1)]`, "[10, 0, 0]"},
+
+		{`import "std/functional" as f; f.identity(1)`, "1"},
+		{`import "std/functional" as f; map([1, 2], f.constant(0))`, "[0, 0]"},
+		{`import "std/functional" as f; f.compose(fn(x) { x * 2 }, fn(x) { x + 1 })(3)`, "8"},
+		{`import "std/functional" as f; f.pipe([fn(x) { x * 2 }, fn(x) { x + 1 }])(3)`, "7"},
+		{`import "std/functional" as f; f.pipe([])(3)`, "3"},
+		{`import "std/functional" as f; f.flip(fn(a, b) { a - b })(1, 3)`, "2"},
+		{`import "std/functional" as f; map([1, 2], f.partial(fn(a, b) { a * b }, 10))`, "[10, 20]"},
+		{`import "std/functional" as f; filter([1, 2, 3], f.negate(fn(x) { x == 2 }))`, "[1, 3]"},
+
+		{`import "std/testing" as t; t.assert(1 < 2, "math")`, "true"},
+		{`import "std/testing" as t; t.assert(1 > 2, "math is broken")`, "error: math is broken"},
+		{`import "std/testing" as t; t.assert_eq([1, 2], [1, 2])`, "true"},
+		{`import "std/testing" as t; t.assert_eq(1 + 1, 3)`, "error: expected 3, got 2"},
+		{`import "std/testing" as t; t.assert_throws(fn() { 1 / 0 })["message"]`, "division by zero"},
+		{`import "std/testing" as t; t.assert_throws(fn() { 1 })`, "error: expected an error"},
+		{
+			`import "std/testing" as t;
+			t.run([
+				["ok", fn() { t.assert_eq(1, 1) }],
+				["bad", fn() { t.assert_eq(1, 2) }],
+				["boom", fn() { 1 / 0 }]
+			])`,
+			"[bad: expected 2, got 1, boom: division by zero]",
+		},
+
+		{`import "std/nope"`, "error: import: module not found: std/nope"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated == nil {
+			t.Errorf("%s: no value", tt.input)
+			continue
+		}
+		if actual := evaluated.Inspect(); actual != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestLoadStdlib(t *testing.T) {
+	in := evaluator.New()
+	env := obj.NewEnv()
+
+	if err := in.LoadStdlib(env); err != nil {
+		t.Fatalf("LoadStdlib: %s", err.Inspect())
+	}
+
+	for _, name := range []string{"list", "string", "math", "functional", "testing"} {
+		mod, ok := env.Get(name)
+		if !ok {
+			t.Errorf("%s not loaded", name)
+			continue
+		}
+		if mod.Type() != obj.MODULE {
+			t.Errorf("%s is %s, want MODULE", name, mod.Type())
+		}
+	}
+
+	// Preloaded modules are the ones the imports return.
+	prog := parser.New(lexer.Lex(`import "std/list" as l; l == list`)).Parse()
+	if res := in.Eval(prog, env); res != evaluator.TRUE {
+		t.Errorf("import returned a different module, got %v", res)
+	}
+}
+
+// The modules of the standard library must pass the same checks as the
+// user programs.
+func TestVet(t *testing.T) {
+	files, err := fs.Glob(stdlib.FS, "*.mk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		src, err := stdlib.FS.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := parser.New(lexer.Lex(string(src)))
+		prog := p.Parse()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Errorf("%s: parser errors: %v", f, errs)
+			continue
+		}
+		for _, i := range lint.Check(prog) {
+			t.Errorf("%s: %s", f, i)
+		}
+		for _, e := range typecheck.Check(prog) {
+			t.Errorf("%s: %s", f, e)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+export let capitalize = fn(s) {
+	if (s == "") { return s; }
+	upper(s[0]) + s[1:]
+};
+
+export let title = fn(s) {
+	join(map(split(s), capitalize), " ")
+};
+
+export let is_blank = fn(s) {
+	trim(s) == ""
+};
+
+export let center = fn(s, width) {
+	let n = rune_len(s);
+	if (n >= width) { return s; }
+	let left = (width - n) / 2;
+	pad_right(pad_left(s, n + left), width)
+};
+
+export let reversed = fn(s) {
+	join(reverse(split(s, "")), "")
+};
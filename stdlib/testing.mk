@@ -0,0 +1,20 @@
+export let assert = fn(cond, msg) {
+	if (!cond) { throw error(msg, "assertion"); }
+	true
+};
+
+export let assert_eq = fn(got, want) {
+	if (got != want) { throw error(format("expected %v, got %v", want, got), "assertion"); }
+	true
+};
+
+export let assert_throws = fn(f) {
+	try { f() } catch (e) { return e; };
+	throw error("expected an error", "assertion")
+};
+
+export let run = fn(cases) {
+	reduce(cases, fn(failed, c) {
+		try { c[1](); failed } catch (e) { push(failed, c[0] + ": " + e["message"]) }
+	}, [])
+};
@@ -17,6 +17,7 @@ const (
 	// Identifiers and literals.
 	IDENT // function names, variable names...
 	INT   // Integer
+	FLOAT
 	STRING
 
 	// Operators.
@@ -33,10 +34,13 @@ const (
 	GT
 	LT_EQ
 	GT_EQ
+	ARROW
 
 	// Delimiters.
 	COMMA
 	SEMICOLON
+	COLON
+	DOT
 	LPAREN
 	RPAREN
 	LBRACE
@@ -52,6 +56,11 @@ const (
 	RETURN
 	TRUE
 	FALSE
+	TRY
+	CATCH
+	THROW
+	IMPORT
+	EXPORT
 )
 
 // Useful to get the string representation of the type.
@@ -61,6 +70,7 @@ var typemap = map[Type]string{
 
 	IDENT:  "IDENT",
 	INT:    "INT",
+	FLOAT:  "FLOAT",
 	STRING: "STRING",
 
 	ASSIGN:   "=",
@@ -76,9 +86,12 @@ var typemap = map[Type]string{
 	GT:       "<",
 	LT_EQ:    "<=",
 	GT_EQ:    ">=",
+	ARROW:    "->",
 
 	COMMA:     ",",
 	SEMICOLON: ";",
+	COLON:     ":",
+	DOT:       ".",
 
 	LPAREN:   "(",
 	RPAREN:   ")",
@@ -94,6 +107,11 @@ var typemap = map[Type]string{
 	RETURN:   "RETURN",
 	TRUE:     "TRUE",
 	FALSE:    "FALSE",
+	TRY:      "TRY",
+	CATCH:    "CATCH",
+	THROW:    "THROW",
+	IMPORT:   "IMPORT",
+	EXPORT:   "EXPORT",
 }
 
 var keywords = map[string]Type{
@@ -104,6 +122,11 @@ var keywords = map[string]Type{
 	"return": RETURN,
 	"true":   TRUE,
 	"false":  FALSE,
+	"try":    TRY,
+	"catch":  CATCH,
+	"throw":  THROW,
+	"import": IMPORT,
+	"export": EXPORT,
 }
 
 func (t Token) String() string {
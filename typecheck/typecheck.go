@@ -0,0 +1,467 @@
+// Package typecheck infers the types of a Monkey program and reports the
+// operations that would fail at runtime with a type error.
+//
+// Annotations are optional: whatever can't be inferred is given the type any,
+// which is compatible with everything, so unannotated code is only checked
+// where the types of the operands are evident.
+package typecheck
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/NicoNex/monkey/ast"
+)
+
+// Error is a type error found in the program.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.Pos, e.Msg)
+}
+
+type scope struct {
+	outer *scope
+	types map[string]*Type
+	// Return type of the function the scope belongs to, nil at top level.
+	ret *Type
+	// Join of the types returned by return statements.
+	returned *Type
+}
+
+type checker struct {
+	errors []Error
+}
+
+// Check returns the type errors found in prog sorted by position.
+func Check(prog *ast.Program) []Error {
+	var c checker
+
+	c.statements(prog.Statements, newScope(nil, nil))
+	sort.SliceStable(c.errors, func(i, j int) bool {
+		return c.errors[i].Pos < c.errors[j].Pos
+	})
+	return c.errors
+}
+
+func newScope(outer *scope, ret *Type) *scope {
+	return &scope{outer: outer, types: make(map[string]*Type), ret: ret}
+}
+
+func (s *scope) lookup(name string) *Type {
+	for ; s != nil; s = s.outer {
+		if t, ok := s.types[name]; ok {
+			return t
+		}
+	}
+	return anyType
+}
+
+func (c *checker) errorf(pos int, format string, a ...interface{}) {
+	c.errors = append(c.errors, Error{Pos: pos, Msg: fmt.Sprintf(format, a...)})
+}
+
+// Checks stmts and returns the type of the value they evaluate to.
+func (c *checker) statements(stmts []ast.Statement, s *scope) *Type {
+	var ret = anyType
+
+	for _, stmt := range stmts {
+		ret = c.statement(stmt, s)
+	}
+	return ret
+}
+
+func (c *checker) statement(stmt ast.Statement, s *scope) *Type {
+	switch stmt := stmt.(type) {
+
+	case *ast.LetStatement:
+		c.let(stmt, s)
+		return anyType
+
+	case *ast.ImportStatement:
+		s.types[stmt.Name.Value] = anyType
+		return anyType
+
+	case *ast.ReturnStatement:
+		t := c.expression(stmt.Value, s)
+		if s.ret != nil && !t.assignable(s.ret) {
+			c.errorf(stmt.Token.Pos, "cannot return %s from function returning %s", t, s.ret)
+		}
+		if s.returned == nil {
+			s.returned = t
+		} else {
+			s.returned = join(s.returned, t)
+		}
+		return t
+
+	case *ast.ThrowStatement:
+		if t := c.expression(stmt.Value, s); t.Kind != Any && t.Kind != String && t.Kind != Err {
+			c.errorf(stmt.Token.Pos, "cannot throw %s", t)
+		}
+		return anyType
+
+	case *ast.ExpressionStatement:
+		return c.expression(stmt.Expr, s)
+
+	case *ast.BlockStatement:
+		return c.block(stmt, s)
+	}
+	return anyType
+}
+
+// Checks the block b, which may not run or stop halfway, in a scope of its
+// own. At runtime the block shares the environment of s, so the names it
+// binds are then bound in s to the join of their types before and in it.
+func (c *checker) block(b *ast.BlockStatement, s *scope) *Type {
+	var inner = newScope(s, s.ret)
+
+	t := c.statements(b.Statements, inner)
+	for name, u := range inner.types {
+		s.types[name] = join(s.lookup(name), u)
+	}
+	if inner.returned != nil {
+		if s.returned == nil {
+			s.returned = inner.returned
+		} else {
+			s.returned = join(s.returned, inner.returned)
+		}
+	}
+	return t
+}
+
+func (c *checker) let(stmt *ast.LetStatement, s *scope) {
+	var annot = c.annotation(stmt.Name.Type)
+
+	// Bind functions before checking their body so they can recur.
+	if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok && stmt.Name.Type == nil {
+		s.types[stmt.Name.Value] = c.signature(fn)
+	}
+
+	t := c.expression(stmt.Value, s)
+	if !t.assignable(annot) {
+		c.errorf(stmt.Token.Pos, "cannot use %s as %s in let %s", t, annot, stmt.Name.Value)
+	}
+
+	if stmt.Name.Type != nil {
+		s.types[stmt.Name.Value] = annot
+	} else {
+		s.types[stmt.Name.Value] = t
+	}
+}
+
+// Returns the type of fn as declared by its annotations.
+func (c *checker) signature(fn *ast.FunctionLiteral) *Type {
+	var t = &Type{Kind: Func, Ret: c.annotation(fn.Ret)}
+
+	for _, p := range fn.Params {
+		t.Params = append(t.Params, c.annotation(p.Type))
+	}
+	return t
+}
+
+func (c *checker) expression(expr ast.Expression, s *scope) *Type {
+	switch expr := expr.(type) {
+
+	case *ast.IntegerLiteral:
+		return intType
+
+	case *ast.FloatLiteral:
+		return floatType
+
+	case *ast.StringLiteral:
+		return stringType
+
+	case *ast.Boolean:
+		return boolType
+
+	case *ast.Identifier:
+		return s.lookup(expr.Value)
+
+	case *ast.PrefixExpression:
+		return c.prefix(expr, s)
+
+	case *ast.InfixExpression:
+		return c.infix(expr, s)
+
+	case *ast.IfExpression:
+		c.expression(expr.Condition, s)
+		cons := c.block(expr.Consequence, s)
+		if expr.Alternative == nil {
+			return anyType
+		}
+		return join(cons, c.block(expr.Alternative, s))
+
+	case *ast.TryExpression:
+		body := c.block(expr.Body, s)
+		s.types[expr.Param.Value] = errorType
+		return join(body, c.block(expr.Catch, s))
+
+	case *ast.FunctionLiteral:
+		return c.function(expr, s)
+
+	case *ast.CallExpression:
+		return c.call(expr, s)
+
+	case *ast.ArrayLiteral:
+		if len(expr.Elements) == 0 {
+			return arrayOf(anyType)
+		}
+		elem := c.expression(expr.Elements[0], s)
+		for _, e := range expr.Elements[1:] {
+			elem = join(elem, c.expression(e, s))
+		}
+		return arrayOf(elem)
+
+	case *ast.IndexExpression:
+		return c.index(expr, s)
+
+	case *ast.SliceExpression:
+		return c.slice(expr, s)
+
+	case *ast.HashLiteral:
+		for _, k := range expr.Keys {
+			if t := c.expression(k, s); !hashable(t) {
+				c.errorf(expr.Token.Pos, "unusable as hash key: %s", t)
+			}
+			c.expression(expr.Pairs[k], s)
+		}
+		return hashType
+	}
+	return anyType
+}
+
+func (c *checker) prefix(expr *ast.PrefixExpression, s *scope) *Type {
+	var right = c.expression(expr.Right, s)
+
+	switch expr.Operator {
+	case "!":
+		return boolType
+
+	case "-":
+		switch right.Kind {
+		case Int, Float, Any:
+			return right
+		}
+		c.errorf(expr.Token.Pos, "unknown operator: -%s", right)
+		return intType
+	}
+	return anyType
+}
+
+func (c *checker) infix(expr *ast.InfixExpression, s *scope) *Type {
+	var (
+		op    = expr.Operator
+		left  = c.expression(expr.Left, s)
+		right = c.expression(expr.Right, s)
+		pos   = expr.Token.Pos
+	)
+
+	switch {
+	case left.Kind == Any && right.Kind == Any:
+		return anyType
+
+	case left.Kind == Any || right.Kind == Any:
+		if isComparison(op) {
+			return boolType
+		}
+		if left.Kind == Any {
+			return right
+		}
+		return left
+
+	case left.Kind == Int && right.Kind == Int:
+		if isComparison(op) {
+			return boolType
+		}
+		if op == "**" {
+			c.errorf(pos, "unknown operator: %s %s %s", left, op, right)
+		}
+		return intType
+
+	case isNumeric(left) && isNumeric(right):
+		if isComparison(op) {
+			return boolType
+		}
+		if op == "**" {
+			c.errorf(pos, "unknown operator: %s %s %s", left, op, right)
+		}
+		return floatType
+
+	case left.Kind == String && right.Kind == String:
+		if isComparison(op) {
+			return boolType
+		}
+		if op == "+" {
+			return stringType
+		}
+		c.errorf(pos, "invalid operator: %s %s %s", left, op, right)
+		return anyType
+
+	case left.Kind == Array && right.Kind == Array && isComparison(op):
+		return boolType
+
+	case op == "==" || op == "!=":
+		return boolType
+
+	case left.Kind != right.Kind:
+		c.errorf(pos, "type mismatch: %s %s %s", left, op, right)
+		return anyType
+
+	default:
+		c.errorf(pos, "unknown operator: %s %s %s", left, op, right)
+		return anyType
+	}
+}
+
+func isNumeric(t *Type) bool {
+	return t.Kind == Int || t.Kind == Float
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", ">", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+func (c *checker) function(fn *ast.FunctionLiteral, s *scope) *Type {
+	var (
+		t    = c.signature(fn)
+		body = newScope(s, t.Ret)
+	)
+
+	for i, p := range fn.Params {
+		body.types[p.Value] = t.Params[i]
+	}
+
+	last := c.statements(fn.Body.Statements, body)
+	if n := len(fn.Body.Statements); n > 0 {
+		// A trailing return has already been checked.
+		if _, ok := fn.Body.Statements[n-1].(*ast.ReturnStatement); !ok && !last.assignable(t.Ret) {
+			c.errorf(fn.Token.Pos, "cannot return %s from function returning %s", last, t.Ret)
+		}
+	}
+
+	if fn.Ret == nil {
+		t.Ret = last
+		if body.returned != nil {
+			t.Ret = join(body.returned, last)
+		}
+	}
+	return t
+}
+
+func (c *checker) call(call *ast.CallExpression, s *scope) *Type {
+	var fn = c.expression(call.Func, s)
+
+	var args []*Type
+	for _, a := range call.Args {
+		args = append(args, c.expression(a, s))
+	}
+
+	switch fn.Kind {
+	case Any:
+		return anyType
+
+	case Func:
+		for i, a := range args {
+			if i < len(fn.Params) && !a.assignable(fn.Params[i]) {
+				c.errorf(
+					call.Token.Pos,
+					"%s: cannot use %s as %s in argument %d",
+					funcName(call.Func),
+					a,
+					fn.Params[i],
+					i+1,
+				)
+			}
+		}
+		return fn.Ret
+
+	default:
+		c.errorf(call.Token.Pos, "not a function: %s", fn)
+		return anyType
+	}
+}
+
+func (c *checker) index(expr *ast.IndexExpression, s *scope) *Type {
+	var (
+		left  = c.expression(expr.Left, s)
+		index = c.expression(expr.Index, s)
+	)
+
+	switch left.Kind {
+	case Any:
+		return anyType
+
+	case Array:
+		if index.Kind != Int && index.Kind != Any {
+			c.errorf(expr.Token.Pos, "cannot index %s with %s", left, index)
+		}
+		return left.Elem
+
+	case String:
+		if index.Kind != Int && index.Kind != Any {
+			c.errorf(expr.Token.Pos, "cannot index %s with %s", left, index)
+		}
+		return stringType
+
+	case Err:
+		if index.Kind != String && index.Kind != Any {
+			c.errorf(expr.Token.Pos, "cannot index %s with %s", left, index)
+		}
+		return anyType
+
+	case Hash:
+		if !hashable(index) {
+			c.errorf(expr.Token.Pos, "unusable as hash key: %s", index)
+		}
+		return anyType
+
+	default:
+		c.errorf(expr.Token.Pos, "index operator not supported: %s", left)
+		return anyType
+	}
+}
+
+func (c *checker) slice(expr *ast.SliceExpression, s *scope) *Type {
+	var left = c.expression(expr.Left, s)
+
+	for _, b := range []ast.Expression{expr.Start, expr.End, expr.Step} {
+		if b == nil {
+			continue
+		}
+		if t := c.expression(b, s); t.Kind != Int && t.Kind != Any {
+			c.errorf(expr.Token.Pos, "cannot slice %s with %s", left, t)
+		}
+	}
+
+	switch left.Kind {
+	case Any, Array, String:
+		return left
+	default:
+		c.errorf(expr.Token.Pos, "slice operator not supported: %s", left)
+		return anyType
+	}
+}
+
+// Reports whether values of type t can be used as hash keys.
+func hashable(t *Type) bool {
+	switch t.Kind {
+	case Any, Int, String, Bool:
+		return true
+	}
+	return false
+}
+
+// Returns the name used in error messages for the function called by fn.
+func funcName(fn ast.Expression) string {
+	if id, ok := fn.(*ast.Identifier); ok {
+		return id.Value
+	}
+	return "fn"
+}
@@ -0,0 +1,112 @@
+package typecheck
+
+import (
+	"testing"
+
+	"github.com/NicoNex/monkey/lexer"
+	"github.com/NicoNex/monkey/parser"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// Unannotated code is checked only where the types are evident.
+		{"let add = fn(a, b) { a + b }; add(1, 2); add(\"a\", \"b\");", nil},
+		{"5 + \"five\"", []string{"type mismatch: int + string"}},
+		{"let x = 5; let y = \"a\"; x + y", []string{"type mismatch: int + string"}},
+		{"-true", []string{"unknown operator: -bool"}},
+		{"true + false", []string{"unknown operator: bool + bool"}},
+		{"\"a\" - \"b\"", []string{"invalid operator: string - string"}},
+		{"(\"a\" < \"b\") + 1", []string{"type mismatch: bool + int"}},
+		{"([1] < [2]) + 1", []string{"type mismatch: bool + int"}},
+		{"[1] + [2]", []string{"unknown operator: [int] + [int]"}},
+		{"[1, 2] == 3", nil},
+		{"5(1)", []string{"not a function: int"}},
+		{"5[0]", []string{"index operator not supported: int"}},
+		{"[1, 2][\"a\"]", []string{"cannot index [int] with string"}},
+		{"[1, 2][0] + \"a\"", []string{"type mismatch: int + string"}},
+		{"{\"a\": 1}[\"a\"] + 1", nil},
+		{"{[1]: 1}", []string{"unusable as hash key: [int]"}},
+		{"\"abc\"[0] + 1", []string{"type mismatch: string + int"}},
+		{"\"abc\"[true]", []string{"cannot index string with bool"}},
+		{"[1, 2][1:][0] + \"a\"", []string{"type mismatch: int + string"}},
+		{"\"abc\"[:\"b\"]", []string{"cannot slice string with string"}},
+		{"5[1:]", []string{"slice operator not supported: int"}},
+		{"throw 5", []string{"cannot throw int"}},
+		{"try { 1 } catch (e) { e[\"message\"] }", nil},
+		{"try { 1 } catch (e) { e[0] }", []string{"cannot index error with int"}},
+		{"let f = fn(e: error) { e }; f(1)", []string{"f: cannot use int as error in argument 1"}},
+		{"let h: hash = {}; h[fn() { 1 }]", []string{"unusable as hash key: fn() -> int"}},
+
+		// Annotations.
+		{"let x: int = 5;", nil},
+		{"let x: float = 1 + 0.5;", nil},
+		{"let x: float = -1.5;", nil},
+		{"let x: int = 2 * 1.5;", []string{"cannot use float as int in let x"}},
+		{"1.5 < 2", nil},
+		{"1.5 + \"a\"", []string{"type mismatch: float + string"}},
+		{"let x: int = \"5\";", []string{"cannot use string as int in let x"}},
+		{"let x: [int] = [1, 2];", nil},
+		{"let x: [int] = [\"a\"];", []string{"cannot use [string] as [int] in let x"}},
+		{"let x: [int] = [];", nil},
+		{"let x: foo = 1;", []string{"unknown type: foo"}},
+		{"let x: string = 1 + 2;", []string{"cannot use int as string in let x"}},
+		{
+			"let f = fn(a: string, b: [int]) -> bool { len(b) > 0 }; f(1, [1]);",
+			[]string{"f: cannot use int as string in argument 1"},
+		},
+		{
+			"let f = fn(a: string) -> bool { a }",
+			[]string{"cannot return string from function returning bool"},
+		},
+		{
+			"let f = fn(a: int) -> int { if (a > 0) { return \"a\" }; a }",
+			[]string{"cannot return string from function returning int"},
+		},
+		{"let f = fn(a: int) { a + \"a\" }", []string{"type mismatch: int + string"}},
+		{"let f = fn() -> int { 1 }; let x: string = f();", []string{"cannot use int as string in let x"}},
+		// Inferred return types.
+		{"let f = fn() { 1 }; f() + \"a\"", []string{"type mismatch: int + string"}},
+		{"let f = fn(x) { if (x) { return \"a\" }; 1 }; f(true) + \"a\"", nil},
+		{
+			"let f: fn(int) -> int = fn(a: string) -> int { 1 };",
+			[]string{"cannot use fn(string) -> int as fn(int) -> int in let f"},
+		},
+		// Blocks have a scope of their own, but the names they bind are
+		// visible after them as they may or may not have been rebound.
+		{"let c = true; let x: int = 1; if (c) { let x: string = \"a\"; x + 1 }", []string{"type mismatch: string + int"}},
+		{"let c = true; let x: int = 1; if (c) { let x: string = \"a\"; }; x + 1", nil},
+		{"let c = true; let x: int = 1; if (c) { let x = 2; }; x + \"a\"", []string{"type mismatch: int + string"}},
+		{"let c = true; if (c) { let y = 1; } else { let y = 2; }; y + \"a\"", nil},
+		{"let x = 1; try { let x = \"a\"; } catch (e) { 1 }; x + 1", nil},
+		{
+			"let f = fn(a: int) -> int { if (a > 0) { if (a > 1) { return \"a\" } }; a }",
+			[]string{"cannot return string from function returning int"},
+		},
+		{"let f = fn(x) { if (x) { if (x) { return \"a\" } }; 1 }; f(true) + \"a\"", nil},
+		// Recursive functions.
+		{"let fib = fn(n: int) -> int { if (n < 2) { return n }; fib(n - 1) + fib(n - 2) }; fib(\"a\")",
+			[]string{"fib: cannot use string as int in argument 1"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.Lex(tt.input))
+		program := p.Parse()
+		if errs := p.Errors(); len(errs) != 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, errs)
+		}
+
+		errs := Check(program)
+		if len(errs) != len(tt.expected) {
+			t.Errorf("%q: wrong errors, got %v, want %q", tt.input, errs, tt.expected)
+			continue
+		}
+		for i, e := range errs {
+			if e.Msg != tt.expected[i] {
+				t.Errorf("%q: wrong error, got %q, want %q", tt.input, e.Msg, tt.expected[i])
+			}
+		}
+	}
+}
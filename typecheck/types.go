@@ -0,0 +1,153 @@
+package typecheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NicoNex/monkey/ast"
+)
+
+type Kind int
+
+const (
+	Any Kind = iota
+	Int
+	Float
+	String
+	Bool
+	Null
+	Array
+	Func
+	Hash
+	Err
+)
+
+var kindrepr = map[Kind]string{
+	Any:    "any",
+	Int:    "int",
+	Float:  "float",
+	String: "string",
+	Bool:   "bool",
+	Null:   "null",
+	Array:  "array",
+	Func:   "fn",
+	Hash:   "hash",
+	Err:    "error",
+}
+
+// Type is the static type of an expression.
+type Type struct {
+	Kind   Kind
+	Elem   *Type   // element type of arrays
+	Params []*Type // parameter types of functions
+	Ret    *Type   // return type of functions
+}
+
+var (
+	anyType    = &Type{Kind: Any}
+	intType    = &Type{Kind: Int}
+	floatType  = &Type{Kind: Float}
+	stringType = &Type{Kind: String}
+	boolType   = &Type{Kind: Bool}
+	nullType   = &Type{Kind: Null}
+	hashType   = &Type{Kind: Hash}
+	errorType  = &Type{Kind: Err}
+)
+
+// Maps the names usable in annotations to their types.
+var named = map[string]*Type{
+	"any":    anyType,
+	"int":    intType,
+	"float":  floatType,
+	"string": stringType,
+	"bool":   boolType,
+	"null":   nullType,
+	"hash":   hashType,
+	"error":  errorType,
+}
+
+func (t *Type) String() string {
+	switch t.Kind {
+	case Array:
+		return fmt.Sprintf("[%s]", t.Elem)
+
+	case Func:
+		var params []string
+
+		for _, p := range t.Params {
+			params = append(params, p.String())
+		}
+		return fmt.Sprintf("fn(%s) -> %s", strings.Join(params, ", "), t.Ret)
+
+	default:
+		return kindrepr[t.Kind]
+	}
+}
+
+func arrayOf(elem *Type) *Type {
+	return &Type{Kind: Array, Elem: elem}
+}
+
+// Reports whether a value of type t can be used where u is expected.
+func (t *Type) assignable(u *Type) bool {
+	if t.Kind == Any || u.Kind == Any {
+		return true
+	}
+	if t.Kind != u.Kind {
+		return false
+	}
+
+	switch t.Kind {
+	case Array:
+		return t.Elem.assignable(u.Elem)
+
+	case Func:
+		if len(t.Params) != len(u.Params) {
+			return false
+		}
+		for i, p := range t.Params {
+			if !u.Params[i].assignable(p) {
+				return false
+			}
+		}
+		return t.Ret.assignable(u.Ret)
+
+	default:
+		return true
+	}
+}
+
+// Returns the type both t and u have, or any if they differ.
+func join(t, u *Type) *Type {
+	if t.assignable(u) && u.assignable(t) && t.Kind != Any {
+		return t
+	}
+	return anyType
+}
+
+// Converts the annotation a into a Type, nil annotations are any.
+func (c *checker) annotation(a *ast.Type) *Type {
+	if a == nil {
+		return anyType
+	}
+
+	switch {
+	case a.Elem != nil:
+		return arrayOf(c.annotation(a.Elem))
+
+	case a.Name == "fn":
+		var t = &Type{Kind: Func, Ret: c.annotation(a.Ret)}
+
+		for _, p := range a.Params {
+			t.Params = append(t.Params, c.annotation(p))
+		}
+		return t
+
+	default:
+		if t, ok := named[a.Name]; ok {
+			return t
+		}
+		c.errorf(a.Token.Pos, "unknown type: %s", a.Name)
+		return anyType
+	}
+}
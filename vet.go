@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/NicoNex/monkey/lexer"
+	"github.com/NicoNex/monkey/lint"
+	"github.com/NicoNex/monkey/parser"
+	"github.com/NicoNex/monkey/typecheck"
+)
+
+// Returns the 1-based line and column of the byte offset pos in src.
+func position(src string, pos int) (line, col int) {
+	if pos > len(src) {
+		pos = len(src)
+	}
+	line = strings.Count(src[:pos], "\n") + 1
+	col = pos - strings.LastIndex(src[:pos], "\n")
+	return
+}
+
+// Reports the issues found in the files at paths and returns the exit
+// status of the vet command.
+func vet(paths []string) int {
+	var status int
+
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: monkey vet file...")
+		return 2
+	}
+
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+
+		src := string(b)
+		p := parser.New(lexer.Lex(src))
+		prog := p.Parse()
+		if errs := p.Errors(); len(errs) != 0 {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, e)
+			}
+			status = 1
+			continue
+		}
+
+		for _, i := range lint.Check(prog) {
+			line, col := position(src, i.Pos)
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", path, line, col, i.Msg)
+			status = 1
+		}
+		for _, e := range typecheck.Check(prog) {
+			line, col := position(src, e.Pos)
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", path, line, col, e.Msg)
+			status = 1
+		}
+	}
+	return status
+}